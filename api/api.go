@@ -0,0 +1,251 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+// Package api is the library API of the interfacer, it loads the interfaces and structs of the project as a graph,
+// and adds the new method to the interface and its implementations as a changeset.
+//
+//	g, err := api.Load(api.Options{ProjectDir: "./"})
+//	changes, err := g.AddMethod("i.Component", "Hello(f int64) (int, error)", api.AddMethodOptions{ReturnDefaultValues: "0,nil"})
+//	err = changes.Apply()
+package api
+
+import (
+	"github.com/SimFG/interfacer/scanner"
+	"github.com/SimFG/interfacer/tool"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultExcludeDirs the dirs are always ignored when scanning the project
+var DefaultExcludeDirs = []string{".idea", ".git", "vendor", ".github"}
+
+// Options the options of loading the project
+type Options struct {
+	// ProjectDir the dir of the project, it's required
+	ProjectDir string
+	// ProjectModule the module path of the project, it's read from the nearest `go.mod` file if it's empty
+	ProjectModule string
+	// ExcludeDirs the exact dir names or the gitignore-style patterns of the ignored paths, the DefaultExcludeDirs are always ignored
+	ExcludeDirs []string
+	// ExcludeFiles the gitignore-style patterns of the ignored files, like `*_mock.go`
+	ExcludeFiles []string
+	// IncludeDirs the exact dir names or the gitignore-style patterns of the dirs ignored by the go tool but scanned,
+	// the `testdata` dirs and the dirs beginning with `_` or `.` are ignored by default
+	IncludeDirs     []string
+	EnableGitignore bool
+	// TestFiles how to handle the `_test.go` files, see scanner.TestFilesInclude, it's `include` by default
+	TestFiles string
+	// OnParseError how to handle the files which can't be parsed, see scanner.ParseErrorSkipFile, it's `skip_file` by default.
+	// The skipped files are got by the Graph.Diagnostics
+	OnParseError string
+	// EnableWorkspace scan the modules of the `go.work` file and the local replace directives as one graph
+	EnableWorkspace bool
+	// EnableNestedModules scan the nested modules in the project dir with their own module paths, they are skipped by default
+	EnableNestedModules bool
+	// EnableVendor scan the `vendor` dir read-only
+	EnableVendor bool
+	// Parallel the number of the goroutines parsing the packages, the number of CPUs by default
+	Parallel int
+	// CacheDir cache the scan results in the dir if it isn't empty
+	CacheDir string
+	// EnableWatch keep the results of the files, so the Scanner().Rescan only parses the changed files
+	EnableWatch bool
+	// ShowProgress print the progress of the scan to the stdout
+	ShowProgress bool
+	// DisableImplement don't find the implements, like the sub module only providing the interfaces
+	DisableImplement bool
+}
+
+// Graph the interfaces and structs of the loaded project
+type Graph struct {
+	scanner *scanner.Scanner
+	options Options
+	// readOnlyDirs the files in them are never written, like the `vendor` dir
+	readOnlyDirs []string
+}
+
+// Load scan the project, and get the graph of the interfaces and structs
+func Load(opts Options) (*Graph, error) {
+	projectDir, err := filepath.Abs(opts.ProjectDir)
+	if err != nil {
+		return nil, tool.NewConfigError("project dir", opts.ProjectDir, err.Error())
+	}
+	opts.ProjectDir = projectDir
+	if opts.ProjectModule == "" {
+		opts.ProjectModule = tool.ModulePathOfDir(opts.ProjectDir)
+	}
+	if opts.TestFiles == "" {
+		opts.TestFiles = scanner.TestFilesInclude
+	}
+	if opts.OnParseError == "" {
+		opts.OnParseError = scanner.ParseErrorSkipFile
+	}
+	var checker tool.ConfigChecker
+	checker.CheckProjectDir(opts.ProjectDir)
+	checker.CheckModuleName(opts.ProjectModule)
+	checker.CheckTestFiles(opts.TestFiles)
+	checker.CheckParseErrorMode(opts.OnParseError)
+	if err = checker.Err(); err != nil {
+		return nil, err
+	}
+
+	s := scanner.New(opts.ProjectModule, opts.ProjectDir)
+	if opts.DisableImplement {
+		s.DisableImplementRelation()
+	}
+	s.SetTestFiles(opts.TestFiles)
+	s.SetParseErrorMode(opts.OnParseError)
+	s.SetParallel(opts.Parallel)
+	if opts.CacheDir != "" {
+		s.EnableCache(opts.CacheDir)
+	}
+	if opts.EnableWatch {
+		s.EnableWatch()
+	}
+	if !opts.ShowProgress {
+		s.DisableProgress()
+	}
+	s.SetExcludeFiles(opts.ExcludeFiles)
+	s.SetIncludeDirs(opts.IncludeDirs)
+	if opts.EnableGitignore {
+		s.EnableGitignore()
+	}
+	if opts.EnableNestedModules {
+		s.EnableNestedModules()
+	}
+	if opts.EnableWorkspace {
+		addWorkspaceModules(s, opts.ProjectDir)
+	}
+	var readOnlyDirs []string
+	if root := tool.FindModuleRoot(opts.ProjectDir); root != "" {
+		vendorDir := tool.PathJoin(root, "vendor")
+		readOnlyDirs = append(readOnlyDirs, vendorDir)
+		if opts.EnableVendor {
+			s.AddVendor(vendorDir)
+		}
+	}
+	if err = s.Start(opts.ProjectDir, append(append([]string{}, opts.ExcludeDirs...), DefaultExcludeDirs...)); err != nil {
+		return nil, err
+	}
+	s.Print()
+	return &Graph{scanner: s, options: opts, readOnlyDirs: readOnlyDirs}, nil
+}
+
+// addWorkspaceModules add the member modules of the `go.work` file and the local replace directives of these modules to the scanner
+func addWorkspaceModules(s *scanner.Scanner, projectDir string) {
+	var moduleDirs []string
+	if goWork := tool.FindGoWork(projectDir); goWork != "" {
+		moduleDirs = append(moduleDirs, tool.WorkspaceModuleDirs(goWork)...)
+	}
+	if root := tool.FindModuleRoot(projectDir); root != "" {
+		moduleDirs = append(moduleDirs, root)
+	}
+
+	modules := make(map[string]string)
+	for i := 0; i < len(moduleDirs); i++ {
+		dir := moduleDirs[i]
+		if _, ok := modules[dir]; ok {
+			continue
+		}
+		modules[dir] = tool.ReadModulePath(tool.PathJoin(dir, tool.GoModFile))
+		for _, replaceDir := range tool.LocalReplaces(tool.PathJoin(dir, tool.GoModFile)) {
+			moduleDirs = append(moduleDirs, replaceDir)
+		}
+	}
+	dirs := lo.Keys[string, string](modules)
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		module := modules[dir]
+		if module == "" {
+			tool.Warn("not found the module path", zap.String("dir", dir))
+			continue
+		}
+		s.AddModule(module, dir)
+	}
+}
+
+// Scanner the underlying scanner of the graph
+func (g *Graph) Scanner() *scanner.Scanner {
+	return g.scanner
+}
+
+// Options the options of the graph, the empty options are filled by the default values
+func (g *Graph) Options() Options {
+	return g.options
+}
+
+// Interface get the interface by the full name or the unambiguous short name, like `pkg.Name`.
+// It returns a NotFoundError or an AmbiguousError of the tool package if the name can't be resolved.
+func (g *Graph) Interface(name string) (*scanner.InterfaceInfo, error) {
+	fullName, err := g.scanner.ResolveInterfaceName(name)
+	if err != nil {
+		return nil, err
+	}
+	i := g.scanner.GetInterface(fullName)
+	if i == nil {
+		return nil, &tool.NotFoundError{Kind: "interface", Name: name}
+	}
+	return i, nil
+}
+
+// Struct get the struct by the full name or the unambiguous short name, like `pkg.Name`
+func (g *Graph) Struct(name string) (*scanner.StructInfo, error) {
+	fullName, err := g.scanner.ResolveStructName(name)
+	if err != nil {
+		return nil, err
+	}
+	s := g.scanner.GetStruct(fullName)
+	if s == nil {
+		return nil, &tool.NotFoundError{Kind: "struct", Name: name}
+	}
+	return s, nil
+}
+
+// Implementations get the interfaces implemented by the struct, see scanner.Scanner.GetImplementations
+func (g *Graph) Implementations(structName string) ([]*scanner.Implementation, error) {
+	s, err := g.Struct(structName)
+	if err != nil {
+		return nil, err
+	}
+	return g.scanner.GetImplementations(s.Name()), nil
+}
+
+// Diagnostics the files which can't be parsed and are skipped, see Options.OnParseError
+func (g *Graph) Diagnostics() []*tool.ParseError {
+	return g.scanner.Diagnostics()
+}
+
+// SkippedModules the nested modules in the project dir which aren't scanned, see Options.EnableNestedModules
+func (g *Graph) SkippedModules() []*scanner.Module {
+	return g.scanner.SkippedModules()
+}
+
+// Export get the stable document of the graph, see scanner.Graph
+func (g *Graph) Export() *scanner.Graph {
+	return g.scanner.Graph()
+}
+
+// MergeInterface add the interface of the other graph, like the sub module, and find its implements in this graph.
+// The excluded method is the new method of the interface, which the implements haven't had, and it can be empty.
+func (g *Graph) MergeInterface(other *Graph, name string, excludeMethod string) error {
+	i, err := other.Interface(name)
+	if err != nil {
+		return err
+	}
+	return g.scanner.SubModule(other.scanner, i.Name(), excludeMethod)
+}
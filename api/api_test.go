@@ -0,0 +1,421 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"github.com/SimFG/interfacer/tool"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var project = map[string]string{
+	"go.mod": "module github.com/foo\n\ngo 1.18\n",
+	"i/i.go": "package i\n\n// Component is the component.\ntype Component interface {\n\tClose() error\n}\n",
+	"a/a.go": "package a\n\ntype Foo struct{}\n\nfunc (f *Foo) Close() error {\n\treturn nil\n}\n",
+	"b/b.go": "package b\n\ntype Bar struct{}\n\nfunc (b Bar) Close() error {\n\treturn nil\n}\n",
+	"x/x.go": "package x\n\ntype Alone struct{}\n",
+}
+
+func writeProject(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	var (
+		configErr *tool.ConfigError
+		parseErr  *tool.ParseError
+	)
+	broken := map[string]string{"go.mod": project["go.mod"], "a/a.go": project["a/a.go"], "c/c.go": "package c\n\nfunc (\n"}
+	tests := []struct {
+		name            string
+		files           map[string]string
+		opts            Options
+		wantErr         interface{}
+		wantModule      string
+		wantDiagnostics int
+	}{
+		{name: "module of the go.mod", files: project, wantModule: "github.com/foo"},
+		{name: "module of the options", files: project, opts: Options{ProjectModule: "example.com/bar"}, wantModule: "example.com/bar"},
+		{name: "missing dir", opts: Options{ProjectDir: "missing"}, wantErr: &configErr},
+		{name: "invalid test files", files: project, opts: Options{TestFiles: "all"}, wantErr: &configErr},
+		{name: "skip the broken file", files: broken, wantModule: "github.com/foo", wantDiagnostics: 1},
+		{name: "fail on the broken file", files: broken, opts: Options{OnParseError: "fail"}, wantErr: &parseErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			dir := writeProject(t, tt.files)
+			opts.ProjectDir = filepath.Join(dir, opts.ProjectDir)
+			g, err := Load(opts)
+			if tt.wantErr != nil {
+				if !errors.As(err, tt.wantErr) {
+					t.Fatalf("want the error %T, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if module := g.Options().ProjectModule; module != tt.wantModule {
+				t.Errorf("the module is %s, want %s", module, tt.wantModule)
+			}
+			if diagnostics := g.Diagnostics(); len(diagnostics) != tt.wantDiagnostics {
+				t.Errorf("got the diagnostics %v, want %d", diagnostics, tt.wantDiagnostics)
+			}
+			// the vendor dir of the module is never written
+			if want := []string{filepath.Join(dir, "vendor")}; !reflect.DeepEqual(g.readOnlyDirs, want) {
+				t.Errorf("the read-only dirs are %v, want %v", g.readOnlyDirs, want)
+			}
+		})
+	}
+}
+
+func TestAddMethod(t *testing.T) {
+	var (
+		configErr   *tool.ConfigError
+		notFoundErr *tool.NotFoundError
+	)
+	tests := []struct {
+		name           string
+		interfaceName  string
+		method         string
+		opts           AddMethodOptions
+		wantErr        interface{}
+		wantImplements []string
+		wantSkipped    []string
+		// wantFiles the relative path -> the content in the file after the change
+		wantFiles map[string][]string
+	}{
+		{
+			name:           "all implements",
+			interfaceName:  "i.Component",
+			method:         "Hello(n int) error",
+			opts:           AddMethodOptions{ReturnDefaultValues: "nil"},
+			wantImplements: []string{"github.com/foo/a.Foo", "github.com/foo/b.Bar"},
+			wantFiles: map[string][]string{
+				"i/i.go": {"\tHello(n int) error\n}"},
+				"a/a.go": {"func (f *Foo) Hello(n int) error {\n\treturn nil\n}"},
+				"b/b.go": {"func (b Bar) Hello(n int) error {\n\treturn nil\n}"},
+			},
+		},
+		{
+			name:           "ignore the struct",
+			interfaceName:  "github.com/foo/i.Component",
+			method:         "Hello() error",
+			opts:           AddMethodOptions{ReturnDefaultValues: "nil", IgnoreStructs: []string{"*.Bar"}},
+			wantImplements: []string{"github.com/foo/a.Foo"},
+			wantFiles: map[string][]string{
+				"i/i.go": {"Hello() error"},
+				"a/a.go": {"func (f *Foo) Hello() error"},
+			},
+		},
+		{
+			name:           "include the packages",
+			interfaceName:  "i.Component",
+			method:         "Hello() error",
+			opts:           AddMethodOptions{ReturnDefaultValues: "nil", IncludePackages: []string{"github.com/foo/a"}},
+			wantImplements: []string{"github.com/foo/a.Foo"},
+			wantSkipped:    []string{"github.com/foo/b.Bar"},
+			wantFiles: map[string][]string{
+				"i/i.go": {"Hello() error"},
+				"a/a.go": {"func (f *Foo) Hello() error"},
+			},
+		},
+		{
+			name:           "skip the interface",
+			interfaceName:  "i.Component",
+			method:         "Hello()",
+			opts:           AddMethodOptions{SkipInterface: true},
+			wantImplements: []string{"github.com/foo/a.Foo", "github.com/foo/b.Bar"},
+			wantFiles: map[string][]string{
+				"a/a.go": {"func (f *Foo) Hello() {\n\treturn\n}"},
+				"b/b.go": {"func (b Bar) Hello() {\n\treturn\n}"},
+			},
+		},
+		{name: "not found interface", interfaceName: "i.Missing", method: "Hello()", wantErr: &notFoundErr},
+		{name: "invalid method", interfaceName: "i.Component", method: "Hello(a int, b)", wantErr: &configErr},
+		{name: "wrong return values", interfaceName: "i.Component", method: "Hello() (int, error)", opts: AddMethodOptions{ReturnDefaultValues: "nil"}, wantErr: &configErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeProject(t, project)
+			g, err := Load(Options{ProjectDir: dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := g.AddMethod(tt.interfaceName, tt.method, tt.opts)
+			if tt.wantErr != nil {
+				if !errors.As(err, tt.wantErr) {
+					t.Fatalf("want the error %T, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(c.Implements, ",") != strings.Join(tt.wantImplements, ",") {
+				t.Errorf("the implements are %v, want %v", c.Implements, tt.wantImplements)
+			}
+			if strings.Join(c.Skipped, ",") != strings.Join(tt.wantSkipped, ",") {
+				t.Errorf("the skipped are %v, want %v", c.Skipped, tt.wantSkipped)
+			}
+
+			files := c.Files()
+			if len(files) != len(tt.wantFiles) {
+				t.Errorf("got %d changed files, want %d", len(files), len(tt.wantFiles))
+			}
+			for _, change := range files {
+				rel, _ := filepath.Rel(dir, change.Path)
+				name := filepath.ToSlash(rel)
+				wants, ok := tt.wantFiles[name]
+				if !ok {
+					t.Errorf("unexpected changed file %s", name)
+					continue
+				}
+				for _, want := range wants {
+					if !strings.Contains(string(change.After), want) {
+						t.Errorf("want %q in %s:\n%s", want, name, change.After)
+					}
+				}
+				// nothing is written before the Apply
+				if content, _ := os.ReadFile(change.Path); string(content) != string(change.Before) {
+					t.Errorf("the file %s is written before the Apply", name)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	files := map[string]string{
+		"a/fake_test.go": "package a\n\ntype fakeComponent struct{}\n\nfunc (f fakeComponent) Close() error {\n\treturn nil\n}\n",
+		"a/ext_test.go":  "package a_test\n\ntype extComponent struct{}\n\nfunc (e *extComponent) Close() error {\n\treturn nil\n}\n",
+	}
+	for name, content := range project {
+		files[name] = content
+	}
+	tests := []struct {
+		name       string
+		writeFile  string
+		writePaths map[string]string
+		// wantFiles the relative path -> the package clause of the changed file, and the file is created if the clause is prefixed by `+`
+		wantFiles map[string]string
+	}{
+		{name: "source", writeFile: WriteFileSource, wantFiles: map[string]string{
+			"a/a.go": "package a", "a/fake_test.go": "package a", "a/ext_test.go": "package a_test", "b/b.go": "package b"}},
+		{name: "per type", writeFile: WriteFilePerType, wantFiles: map[string]string{
+			"a/foo_component_gen.go": "+package a", "a/fakecomponent_component_gen_test.go": "+package a",
+			"a/extcomponent_component_gen_test.go": "+package a_test", "b/bar_component_gen.go": "+package b"}},
+		{name: "per package", writeFile: WriteFilePerPackage, wantFiles: map[string]string{
+			"a/zz_interfacer.go": "+package a", "a/zz_interfacer_test.go": "+package a",
+			"a/zz_interfacer_a_test.go": "+package a_test", "b/zz_interfacer.go": "+package b"}},
+		{name: "write paths", writeFile: WriteFilePerPackage, writePaths: map[string]string{"github.com/foo/a.Foo": "a/impl.go", "github.com/foo/b.Bar": "b/b.go"},
+			wantFiles: map[string]string{"a/impl.go": "+package a", "a/zz_interfacer_test.go": "+package a",
+				"a/zz_interfacer_a_test.go": "+package a_test", "b/b.go": "package b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeProject(t, files)
+			g, err := Load(Options{ProjectDir: dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+			writePaths := make(map[string]string)
+			for name, path := range tt.writePaths {
+				writePaths[name] = filepath.Join(dir, filepath.FromSlash(path))
+			}
+			c, err := g.AddMethod("i.Component", "Hello() error", AddMethodOptions{ReturnDefaultValues: "nil", SkipInterface: true,
+				WriteFile: tt.writeFile, WritePaths: writePaths})
+			if err != nil {
+				t.Fatal(err)
+			}
+			changes := c.Files()
+			if len(changes) != len(tt.wantFiles) {
+				t.Errorf("got %d changed files, want %d", len(changes), len(tt.wantFiles))
+			}
+			for _, change := range changes {
+				rel, _ := filepath.Rel(dir, change.Path)
+				name := filepath.ToSlash(rel)
+				want, ok := tt.wantFiles[name]
+				if !ok {
+					t.Errorf("unexpected changed file %s", name)
+					continue
+				}
+				if created := strings.HasPrefix(want, "+"); created != (change.Before == nil) {
+					t.Errorf("the file %s is created: %v, want %v", name, change.Before == nil, created)
+				}
+				if !strings.HasPrefix(string(change.After), strings.TrimPrefix(want, "+")+"\n") {
+					t.Errorf("want the clause %q in %s:\n%s", want, name, change.After)
+				}
+				if !strings.Contains(string(change.After), ") Hello() error {") {
+					t.Errorf("the method isn't written to %s:\n%s", name, change.After)
+				}
+			}
+		})
+	}
+}
+
+func TestAddMethodDoc(t *testing.T) {
+	files := map[string]string{
+		"b/b.go":    "package b\n\nimport iface \"github.com/foo/i\"\n\nvar _ iface.Component = Bar{}\n\ntype Bar struct{}\n\nfunc (b Bar) Close() error {\n\treturn nil\n}\n",
+		"i/impl.go": "package i\n\ntype Impl struct{}\n\nfunc (m *Impl) Close() error {\n\treturn nil\n}\n",
+	}
+	for name, content := range project {
+		if _, ok := files[name]; !ok {
+			files[name] = content
+		}
+	}
+	var configErr *tool.ConfigError
+	tests := []struct {
+		name         string
+		methodDoc    string
+		implementDoc string
+		wantErr      bool
+		// wantFiles the relative path -> the content in the file after the change
+		wantFiles map[string]string
+	}{
+		{
+			name:         "doc",
+			methodDoc:    "Hello says hello.\nIt's new.",
+			implementDoc: "{{.Method}} implements {{.Interface}} for {{.Struct}}.",
+			wantFiles: map[string]string{
+				"i/i.go":    "\t// Hello says hello.\n\t// It's new.\n\tHello() error\n}",
+				"a/a.go":    "// Hello implements i.Component for Foo.\nfunc (f *Foo) Hello() error {",
+				"b/b.go":    "// Hello implements iface.Component for Bar.\nfunc (b Bar) Hello() error {",
+				"i/impl.go": "// Hello implements Component for Impl.\nfunc (m *Impl) Hello() error {",
+			},
+		},
+		{
+			name:         "full name",
+			implementDoc: "// {{.Method}} is required by {{.InterfaceFullName}}",
+			wantFiles: map[string]string{
+				"i/i.go":    "\tClose() error\n\n\tHello() error\n}",
+				"a/a.go":    "}\n\n// Hello is required by github.com/foo/i.Component\nfunc (f *Foo) Hello() error {",
+				"b/b.go":    "}\n\n// Hello is required by github.com/foo/i.Component\nfunc (b Bar) Hello() error {",
+				"i/impl.go": "}\n\n// Hello is required by github.com/foo/i.Component\nfunc (m *Impl) Hello() error {",
+			},
+		},
+		{name: "invalid template", implementDoc: "{{.Method", wantErr: true},
+		{name: "missing field", implementDoc: "{{.Missing}}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeProject(t, files)
+			g, err := Load(Options{ProjectDir: dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := g.AddMethod("i.Component", "Hello() error", AddMethodOptions{ReturnDefaultValues: "nil", MethodDoc: tt.methodDoc, ImplementDoc: tt.implementDoc})
+			if tt.wantErr {
+				if !errors.As(err, &configErr) {
+					t.Fatalf("want the config error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			changes := c.Files()
+			if len(changes) != len(tt.wantFiles) {
+				t.Errorf("got %d changed files, want %d", len(changes), len(tt.wantFiles))
+			}
+			for _, change := range changes {
+				rel, _ := filepath.Rel(dir, change.Path)
+				name := filepath.ToSlash(rel)
+				if want, ok := tt.wantFiles[name]; !ok {
+					t.Errorf("unexpected changed file %s", name)
+				} else if !strings.Contains(string(change.After), want) {
+					t.Errorf("want %q in %s:\n%s", want, name, change.After)
+				}
+			}
+		})
+	}
+}
+
+func TestChangesetApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		readOnly string
+		wantErr  error
+	}{
+		{name: "write the files"},
+		{name: "read-only dir", readOnly: "b", wantErr: tool.ErrReadOnly},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeProject(t, project)
+			g, err := Load(Options{ProjectDir: dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.readOnly != "" {
+				g.readOnlyDirs = append(g.readOnlyDirs, filepath.Join(dir, tt.readOnly))
+			}
+			c, err := g.AddMethod("i.Component", "Hello() error", AddMethodOptions{ReturnDefaultValues: "nil"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = c.Apply()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("want the error %v, got %v", tt.wantErr, err)
+				}
+				// nothing is written if any file is read-only
+				for _, change := range c.Files() {
+					if content, _ := os.ReadFile(change.Path); string(content) != string(change.Before) {
+						t.Errorf("the file %s is written", change.Path)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, change := range c.Files() {
+				if content, err := os.ReadFile(change.Path); err != nil || string(content) != string(change.After) {
+					t.Errorf("the file %s isn't written: %v", change.Path, err)
+				}
+			}
+
+			// the written project has the method, so adding it again changes nothing
+			g, err = Load(Options{ProjectDir: dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c, err = g.AddMethod("i.Component", "Hello() error", AddMethodOptions{ReturnDefaultValues: "nil", SkipInterface: true}); err != nil {
+				t.Fatal(err)
+			}
+			if files := c.Files(); len(files) != 0 {
+				t.Errorf("got %d changed files after the Apply", len(files))
+			}
+		})
+	}
+}
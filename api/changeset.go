@@ -0,0 +1,133 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"github.com/SimFG/interfacer/tool"
+	"github.com/SimFG/interfacer/writer"
+	"os"
+	"sort"
+)
+
+// FileChange the content of the file before and after the change, the Before is nil if the file is created by the change
+type FileChange struct {
+	Path   string
+	Before []byte
+	After  []byte
+}
+
+// Changeset the changes of the files made by the AddMethod, nothing is written to the disk until the Apply is called
+type Changeset struct {
+	// Interface the full name of the interface
+	Interface string
+	Method    string
+	// Implements the full names of the structs receiving the method
+	Implements []string
+	// Skipped the full names of the implements which aren't in the included packages
+	Skipped []string
+	changes map[string]*FileChange
+	// readOnlyDirs the dirs of the Graph which can't be written
+	readOnlyDirs []string
+}
+
+func newChangeset(interfaceName string, method string, readOnlyDirs []string) *Changeset {
+	return &Changeset{
+		Interface:    interfaceName,
+		Method:       method,
+		changes:      make(map[string]*FileChange),
+		readOnlyDirs: readOnlyDirs,
+	}
+}
+
+// Files the changed files, which are sorted by the paths
+func (c *Changeset) Files() []*FileChange {
+	var files []*FileChange
+	for _, change := range c.changes {
+		if !bytes.Equal(change.Before, change.After) {
+			files = append(files, change)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
+
+// Apply write the changed files to the disk, nothing is written if any file is read-only.
+// The failure is returned as a WriteError of the tool package.
+func (c *Changeset) Apply() error {
+	for _, change := range c.Files() {
+		if err := writer.CheckWritable(change.Path, c.readOnlyDirs); err != nil {
+			return err
+		}
+	}
+	for _, change := range c.Files() {
+		mode := os.FileMode(0644)
+		if change.Before != nil {
+			info, err := os.Stat(change.Path)
+			if err != nil {
+				return &tool.WriteError{File: change.Path, Err: err}
+			}
+			mode = info.Mode()
+		}
+		if err := os.WriteFile(change.Path, change.After, mode); err != nil {
+			return &tool.WriteError{File: change.Path, Err: err}
+		}
+	}
+	return nil
+}
+
+// source get the current content of the file, including the changes made before
+func (c *Changeset) source(path string) ([]byte, error) {
+	if change, ok := c.changes[path]; ok {
+		return change.After, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c.changes[path] = &FileChange{Path: path, Before: content, After: content}
+	return content, nil
+}
+
+// touch add the new file having the package clause if the file doesn't exist, and it's created by the Apply
+func (c *Changeset) touch(path string, packageName string) error {
+	if _, ok := c.changes[path]; ok {
+		return nil
+	}
+	_, err := os.Stat(path)
+	if !os.IsNotExist(err) {
+		return err
+	}
+	c.changes[path] = &FileChange{Path: path, After: []byte("package " + packageName + "\n")}
+	return nil
+}
+
+// rewrite replace the content of the file by the fn, which gets the current content
+func (c *Changeset) rewrite(path string, fn func(src []byte) ([]byte, error)) error {
+	src, err := c.source(path)
+	if err != nil {
+		return err
+	}
+	content, err := fn(src)
+	if err != nil {
+		return err
+	}
+	c.changes[path].After = content
+	return nil
+}
@@ -0,0 +1,238 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"github.com/SimFG/interfacer/scanner"
+	"github.com/SimFG/interfacer/tool"
+	"github.com/SimFG/interfacer/writer"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const (
+	// WriteFileSource write the implementation to the first file of the struct
+	WriteFileSource = "source"
+	// WriteFilePerType write the implementation to the `<type>_<interface>_gen.go` file in the dir of the struct
+	WriteFilePerType = "per_type"
+	// WriteFilePerPackage write the implementations to the `zz_interfacer.go` file in the dir of the struct,
+	// and it's the `zz_interfacer_<package>.go` file for the external test package, like `zz_interfacer_foo_test.go`
+	WriteFilePerPackage = "per_package"
+)
+
+// AddMethodOptions the options of adding the new method
+type AddMethodOptions struct {
+	// ReturnDefaultValues the default return values of the implementations, like: nil,nil
+	ReturnDefaultValues string
+	// WritePaths struct full name -> the file which the implementation is written to, it takes precedence over the WriteFile
+	WritePaths map[string]string
+	// MethodDoc the doc comment above the new method of the interface, the `// ` prefix of every line is added if it's missing
+	MethodDoc string
+	// ImplementDoc the text/template of the doc comment above the implementations, like: {{.Method}} implements {{.Interface}}.
+	// The data is ImplementDocData
+	ImplementDoc string
+	// WriteFile which file the implementation is written to, see WriteFileSource, it's WriteFileSource by default.
+	// The missing file is created with the package clause, and the file is `_test.go` if the struct is only declared in the test files
+	WriteFile string
+	// IgnoreStructs the full names, globs or `regexp:` patterns of the structs which don't receive the method
+	IgnoreStructs []string
+	// IncludePackages only the implements in the matched packages receive the method, the others are reported as skipped
+	IncludePackages []string
+	// TestFilesOnly only the structs declared in the `_test.go` files receive the method, like the hand-written fakes
+	TestFilesOnly bool
+	// SkipInterface don't add the method to the interface, like the interface of the sub module
+	SkipInterface bool
+	// InsertPosition where the method is inserted in the interface and the files of the implements, see writer.ParseInsertPosition.
+	// It's the end of the interface and the files by default
+	InsertPosition string
+}
+
+// ImplementDocData the data of the ImplementDoc template
+type ImplementDocData struct {
+	// Method the name of the new method, like: Hello
+	Method string
+	// Interface the interface name used in the file of the implementation, like: i.Component
+	Interface string
+	// InterfaceFullName like: github.com/foo/bar/i.Component
+	InterfaceFullName string
+	// Struct the name of the struct, like: Node
+	Struct string
+}
+
+// shortTypeName get the type name without the package, like: github.com/foo/i.Component -> Component
+func shortTypeName(info *scanner.BaseInfo) string {
+	return info.Name()[strings.LastIndex(info.Name(), ".")+1:]
+}
+
+// implementPath get the file which the implementation of the struct is written to by the WritePaths and the WriteFile
+func implementPath(item *scanner.StructInfo, interfaceInfo *scanner.InterfaceInfo, opts AddMethodOptions) string {
+	if p, ok := opts.WritePaths[item.Name()]; ok {
+		return p
+	}
+	dir := filepath.Dir(item.FilePaths()[0])
+	suffix := lo.Ternary[string](item.IsTestOnly(), "_test.go", ".go")
+	switch opts.WriteFile {
+	case WriteFilePerType:
+		typeName := strings.ToLower(shortTypeName(item.BaseInfo)) + "_" + strings.ToLower(shortTypeName(interfaceInfo.BaseInfo))
+		return filepath.Join(dir, typeName+"_gen"+suffix)
+	case WriteFilePerPackage:
+		// the external test package, like `foo_test`, is in the same dir as the package `foo`, so its file has the package name
+		if strings.HasSuffix(item.PackagePath(), "_test") {
+			return filepath.Join(dir, "zz_interfacer_"+item.PackageName()+".go")
+		}
+		return filepath.Join(dir, "zz_interfacer"+suffix)
+	}
+	return item.FilePaths()[0]
+}
+
+// AddMethod add the new method to the interface and the default implementation to its implements.
+// The interface can be the full name or the unambiguous short name, and the method is the declaration, like: Hello(f int64) (int, error)
+func (g *Graph) AddMethod(interfaceName string, newMethod string, opts AddMethodOptions) (*Changeset, error) {
+	interfaceInfo, err := g.Interface(interfaceName)
+	if err != nil {
+		return nil, err
+	}
+	var checker tool.ConfigChecker
+	checker.CheckInterface(interfaceInfo.Name(), newMethod, opts.ReturnDefaultValues)
+	checker.CheckNamePatterns(opts.IgnoreStructs)
+	checker.CheckInsertPosition(opts.InsertPosition)
+	checker.CheckWriteFile(opts.WriteFile)
+	if err = checker.Err(); err != nil {
+		return nil, err
+	}
+	implementDoc, err := template.New("implement_doc").Parse(opts.ImplementDoc)
+	if err != nil {
+		return nil, tool.NewConfigError("implement doc", opts.ImplementDoc, err.Error())
+	}
+	if !opts.SkipInterface && interfaceInfo.IsReadOnly() {
+		return nil, tool.NewConfigError("interface", interfaceInfo.Name(), "the interface in the vendor dir can't be modified, please configure it as a sub module")
+	}
+
+	decl, err := tool.ParseMethodDecl(newMethod)
+	if err != nil {
+		return nil, err
+	}
+	returnDefaults := strings.Split(opts.ReturnDefaultValues, ",")
+	c := newChangeset(interfaceInfo.Name(), strings.TrimSpace(newMethod), g.readOnlyDirs)
+	position := writer.ParseInsertPosition(opts.InsertPosition)
+	position.Order = lo.Map[*scanner.MethodInfo, string](interfaceInfo.Methods(), func(item *scanner.MethodInfo, _ int) string {
+		return item.Name()
+	})
+	if !lo.Contains[string](position.Order, decl.Name) {
+		position.Order = append(position.Order, decl.Name)
+	}
+	if !opts.SkipInterface {
+		interfaceFileName := interfaceInfo.FilePaths()[0]
+		shortName := shortTypeName(interfaceInfo.BaseInfo)
+		err = c.rewrite(interfaceFileName, func(src []byte) ([]byte, error) {
+			return writer.InsertInterfaceMethod(interfaceFileName, src, shortName, "\t"+c.Method, opts.MethodDoc, position)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the types of the method are written relative to the interface file
+	interfaceSrc, err := c.source(interfaceInfo.FilePaths()[0])
+	if err != nil {
+		return nil, err
+	}
+	interfaceImports, err := g.fileImports(interfaceInfo.FilePaths()[0], interfaceSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	ignoreMatcher, err := tool.NewNameMatcher(opts.IgnoreStructs)
+	if err != nil {
+		return nil, err
+	}
+	includeMatcher, err := tool.NewPackageMatcher(opts.IncludePackages)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range interfaceInfo.GetImplements() {
+		if ignoreMatcher.Match(item.Name()) {
+			continue
+		}
+		if item.IsReadOnly() {
+			tool.Info("skip the read-only struct", zap.String("struct", item.Name()))
+			continue
+		}
+		if opts.TestFilesOnly && !item.IsTestOnly() {
+			tool.Info("skip the struct not in the test files", zap.String("struct", item.Name()))
+			continue
+		}
+		if !includeMatcher.Match(item.PackagePath()) {
+			tool.Info("skip the struct not in the include packages", zap.String("struct", item.Name()))
+			c.Skipped = append(c.Skipped, item.Name())
+			continue
+		}
+		if lo.ContainsBy[*scanner.MethodInfo](item.Methods(), func(m *scanner.MethodInfo) bool { return m.Name() == decl.Name }) {
+			tool.Info("skip the struct having the method", zap.String("struct", item.Name()))
+			continue
+		}
+		writePath := implementPath(item, interfaceInfo, opts)
+		receiverName, receiverType, err := item.MethodReceiver()
+		if err != nil {
+			return nil, err
+		}
+		if err = c.touch(writePath, item.PackageName()); err != nil {
+			return nil, err
+		}
+		err = c.rewrite(writePath, func(src []byte) ([]byte, error) {
+			dstImports, err := g.fileImports(writePath, src)
+			if err != nil {
+				return nil, err
+			}
+			q := &qualifier{g: g, srcPackage: interfaceInfo.PackagePath(), srcImports: interfaceImports,
+				dstPackage: item.PackagePath(), dstImports: dstImports, newImports: make(map[string]string)}
+			paramTypes, err := q.types(decl.ParamTypes)
+			if err != nil {
+				return nil, err
+			}
+			returnTypes, err := q.types(decl.ReturnTypes)
+			if err != nil {
+				return nil, err
+			}
+			var doc strings.Builder
+			err = implementDoc.Execute(&doc, &ImplementDocData{
+				Method:            decl.Name,
+				Interface:         q.typeName(interfaceInfo.PackagePath(), interfaceInfo.PackageName(), shortTypeName(interfaceInfo.BaseInfo)),
+				InterfaceFullName: interfaceInfo.Name(),
+				Struct:            shortTypeName(item.BaseInfo),
+			})
+			if err != nil {
+				return nil, tool.NewConfigError("implement doc", opts.ImplementDoc, err.Error())
+			}
+			newSrc, err := writer.InsertStructMethod(writePath, src, receiverName, receiverType, decl.Name, decl.ParamNames, paramTypes, returnTypes, returnDefaults, doc.String(), position)
+			if err != nil || bytes.Equal(newSrc, src) {
+				return newSrc, err
+			}
+			// the imports are only needed by the new method
+			return q.insertImports(writePath, newSrc)
+		})
+		if err != nil {
+			return nil, err
+		}
+		c.Implements = append(c.Implements, item.Name())
+	}
+	return c, nil
+}
@@ -0,0 +1,145 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/SimFG/interfacer/tool"
+	"github.com/SimFG/interfacer/writer"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fileImports get the imports of the file, the package name -> the import path.
+// The unaliased import is named by the package clause, and the `_` and `.` imports are ignored.
+func (g *Graph) fileImports(fileName string, src []byte) (map[string]string, error) {
+	fileNode, err := parser.ParseFile(token.NewFileSet(), fileName, src, parser.ImportsOnly)
+	if err != nil {
+		return nil, tool.NewParseError(fileName, err)
+	}
+	imports := make(map[string]string)
+	for _, spec := range fileNode.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if spec.Name == nil {
+			imports[g.scanner.PackageName(path)] = path
+			continue
+		}
+		if spec.Name.Name != "_" && spec.Name.Name != "." {
+			imports[spec.Name.Name] = path
+		}
+	}
+	return imports, nil
+}
+
+// qualifier convert the types written in the interface file to the types used in the target file,
+// the qualifiers of the target file are reused, and the missing imports are recorded
+type qualifier struct {
+	g          *Graph
+	srcPackage string
+	srcImports map[string]string
+	dstPackage string
+	dstImports map[string]string
+	newImports map[string]string
+}
+
+// qualify get the qualifier in the target file by the qualifier in the interface file, it's empty if the type is in the target package
+func (q *qualifier) qualify(alias string, _ string) string {
+	path := q.srcPackage
+	if alias != "" {
+		var ok bool
+		if path, ok = q.srcImports[alias]; !ok {
+			// the unknown qualifier is kept, like the package isn't imported by the interface file
+			return alias
+		}
+	}
+	if path == q.dstPackage {
+		return ""
+	}
+	if name, ok := q.importName(path); ok {
+		return name
+	}
+	base := q.g.scanner.PackageName(path)
+	name := base
+	for i := 2; q.dstImports[name] != ""; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	q.dstImports[name] = path
+	q.newImports[name] = path
+	return name
+}
+
+// typeName get the name of the type used in the target file, like `i.Component`, it's used by the comments, so no import is added
+func (q *qualifier) typeName(path string, packageName string, name string) string {
+	if path == q.dstPackage {
+		return name
+	}
+	if importName, ok := q.importName(path); ok {
+		return importName + "." + name
+	}
+	return packageName + "." + name
+}
+
+// importName get the name of the import path in the target file, the first one is used if the path is imported many times
+func (q *qualifier) importName(path string) (string, bool) {
+	var names []string
+	for n, p := range q.dstImports {
+		if p == path {
+			names = append(names, n)
+		}
+	}
+	if len(names) == 0 {
+		return "", false
+	}
+	sort.Strings(names)
+	return names[0], true
+}
+
+// types requalify the types relative to the target file
+func (q *qualifier) types(typs []string) ([]string, error) {
+	result := make([]string, 0, len(typs))
+	for _, typ := range typs {
+		t, err := writer.RequalifyType(strings.TrimSpace(typ), q.qualify)
+		if err != nil {
+			return nil, tool.NewConfigError("method", typ, "the type is invalid, "+err.Error())
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// insertImports add the missing imports to the source of the target file, and the alias is omitted if the package name can be inferred from the import path
+func (q *qualifier) insertImports(fileName string, src []byte) ([]byte, error) {
+	names := make([]string, 0, len(q.newImports))
+	for name := range q.newImports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := q.newImports[name]
+		alias := name
+		if name == q.g.scanner.PackageName(path) && name == tool.ImportName(path) {
+			alias = ""
+		}
+		var err error
+		if src, err = writer.InsertImport(fileName, src, alias, path); err != nil {
+			return nil, err
+		}
+	}
+	return src, nil
+}
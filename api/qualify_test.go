@@ -0,0 +1,76 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/SimFG/interfacer/scanner"
+	"reflect"
+	"testing"
+)
+
+func TestQualifierTypes(t *testing.T) {
+	srcImports := map[string]string{"s": "github.com/foo/s", "ctx": "context"}
+	tests := []struct {
+		name           string
+		dstImports     map[string]string
+		typs           []string
+		want           []string
+		wantNewImports map[string]string
+	}{
+		{name: "builtin", typs: []string{"error", "[]int", "map[string]any"}, want: []string{"error", "[]int", "map[string]any"}, wantNewImports: map[string]string{}},
+		{name: "interface package", typs: []string{"*Node", "[]Node"}, want: []string{"*i.Node", "[]i.Node"},
+			wantNewImports: map[string]string{"i": "github.com/foo/i"}},
+		{name: "target package", typs: []string{"s.Foo", "map[string]*s.Foo"}, want: []string{"Foo", "map[string]*Foo"}, wantNewImports: map[string]string{}},
+		{name: "variadic", typs: []string{"...Node", "...s.Foo"}, want: []string{"...i.Node", "...Foo"},
+			wantNewImports: map[string]string{"i": "github.com/foo/i"}},
+		{name: "func type", typs: []string{"func(ctx.Context) error"}, want: []string{"func(context.Context) error"},
+			wantNewImports: map[string]string{"context": "context"}},
+		{name: "reuse the alias", dstImports: map[string]string{"iface": "github.com/foo/i"}, typs: []string{"Node"}, want: []string{"iface.Node"},
+			wantNewImports: map[string]string{}},
+		{name: "alias collision", dstImports: map[string]string{"i": "github.com/bar/i"}, typs: []string{"Node", "chan Node"}, want: []string{"i2.Node", "chan i2.Node"},
+			wantNewImports: map[string]string{"i2": "github.com/foo/i"}},
+		{name: "unknown qualifier", typs: []string{"x.Bar"}, want: []string{"x.Bar"}, wantNewImports: map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dstImports := map[string]string{}
+			for name, path := range tt.dstImports {
+				dstImports[name] = path
+			}
+			q := &qualifier{g: &Graph{scanner: scanner.New("github.com/foo", t.TempDir())}, srcPackage: "github.com/foo/i", srcImports: srcImports,
+				dstPackage: "github.com/foo/s", dstImports: dstImports, newImports: make(map[string]string)}
+			got, err := q.types(tt.typs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("the types are %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(q.newImports, tt.wantNewImports) {
+				t.Errorf("the new imports are %v, want %v", q.newImports, tt.wantNewImports)
+			}
+		})
+	}
+}
+
+func TestQualifierInvalidType(t *testing.T) {
+	q := &qualifier{g: &Graph{scanner: scanner.New("github.com/foo", t.TempDir())}, srcPackage: "github.com/foo/i",
+		dstPackage: "github.com/foo/s", dstImports: map[string]string{}, newImports: make(map[string]string)}
+	if _, err := q.types([]string{"map[string"}); err == nil {
+		t.Error("the invalid type is requalified")
+	}
+}
@@ -0,0 +1,48 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+// Package commente the heavily commented file, the comments shouldn't be moved after writing the new methods, like:
+// interfacer --interface commente.Commenter --method "Reply(msg string) error" --returns nil --insert-position receiver
+package commente // the trailing comment of the package clause
+
+// the doc of the imports
+import "fmt" // the trailing comment of the import
+
+// Commenter the doc of the interface
+type Commenter interface {
+	// Comment the doc of the method
+	Comment() // the trailing comment of the method
+	// the comment at the end of the interface
+}
+
+/*
+Post the doc of the struct
+in the block comment
+*/
+type Post struct {
+	// the doc of the field
+	title string // the trailing comment of the field
+} // the trailing comment of the struct
+
+// the dangling comment between the declarations
+
+// Comment the doc of the implementation
+func (p *Post) Comment() {
+	// the comment in the body
+	fmt.Println(p.title) // the trailing comment of the statement
+} // the trailing comment of the implementation
+
+// the comment at the end of the file
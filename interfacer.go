@@ -19,16 +19,22 @@ package main
 import (
 	"errors"
 	"fmt"
+	"github.com/SimFG/interfacer/api"
 	"github.com/SimFG/interfacer/scanner"
 	"github.com/SimFG/interfacer/tool"
 	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime/debug"
 	"strings"
 )
 
+const defaultYamlFile = "interfacer.yaml"
+
 type SubModule struct {
 	ProjectDir          string   `yaml:"project_dir"`
 	ProjectModule       string   `yaml:"project_module"`
@@ -36,19 +42,37 @@ type SubModule struct {
 	Method              string   `yaml:"method"`
 	ReturnDefaultValues string   `yaml:"return_default_values"`
 	ExcludeDirs         []string `yaml:"exclude_dirs,flow"`
+	ExcludeFiles        []string `yaml:"exclude_files,flow"`
 }
 
 type Config struct {
 	WritePaths          []string    `yaml:"write_paths,flow"`
 	ExcludeDirs         []string    `yaml:"exclude_dirs,flow"`
+	ExcludeFiles        []string    `yaml:"exclude_files,flow"`
+	IncludeDirs         []string    `yaml:"include_dirs,flow"`
+	EnableGitignore     bool        `yaml:"enable_gitignore"`
 	ProjectDir          string      `yaml:"project_dir"`
 	ProjectModule       string      `yaml:"project_module"`
 	InterfaceFullName   string      `yaml:"interface_full_name"`
 	NewMethod           string      `yaml:"new_method"`
+	NewMethodDoc        string      `yaml:"new_method_doc"`
+	ImplementDoc        string      `yaml:"implement_doc"`
 	ReturnDefaultValues string      `yaml:"return_default_values"`
 	IgnoreStructs       []string    `yaml:"ignore_structs,flow"`
+	IncludePackages     []string    `yaml:"include_packages,flow"`
+	TestFiles           string      `yaml:"test_files"`
+	OnParseError        string      `yaml:"on_parse_error"`
+	InsertPosition      string      `yaml:"insert_position"`
+	WriteFile           string      `yaml:"write_file"`
 	EnableRecord        bool        `yaml:"enable_record"`
 	EnableDebug         bool        `yaml:"enable_debug"`
+	EnableWorkspace     bool        `yaml:"enable_workspace"`
+	EnableNestedModules bool        `yaml:"enable_nested_modules"`
+	EnableVendor        bool        `yaml:"enable_vendor"`
+	Parallel            int         `yaml:"parallel"`
+	EnableCache         bool        `yaml:"enable_cache"`
+	CacheDir            string      `yaml:"cache_dir"`
+	WatchInterval       string      `yaml:"watch_interval"`
 	SubModules          []SubModule `yaml:"sub_modules,flow"`
 }
 
@@ -56,7 +80,10 @@ var (
 	interfacer = &cobra.Command{
 		Use:   "interfacer",
 		Short: "Implement a method of the interface anywhere",
-		Run:   implement,
+		RunE:  implement,
+		// the error is printed by the main with the exit code
+		SilenceUsage:  true,
+		SilenceErrors: true,
 	}
 
 	yamlFile            string
@@ -64,40 +91,91 @@ var (
 	projectModule       string
 	interfaceFullName   string
 	newMethod           string
+	newMethodDoc        string
+	implementDoc        string
 	returnDefaultValues string
+	testFiles           string
+	onParseError        string
+	insertPosition      string
+	writeFile           string
+	enableWorkspace     bool
+	enableNested        bool
+	enableVendor        bool
+	parallel            int
+	enableCache         bool
+	disableProgress     bool
 	writePaths          = make(map[string]string)
 	ignoreStructs       []string
+	includePackages     []string
 	config              = &Config{}
 )
 
 func init() {
-	interfacer.Flags().StringVar(&yamlFile, "yaml-file", "interfacer.yaml", "full project dir")
-	interfacer.Flags().StringVar(&projectDir, "project-dir", config.ProjectDir, "full project dir")
-	interfacer.Flags().StringVar(&projectModule, "project-module", config.ProjectModule, "project module")
-	interfacer.Flags().StringVar(&interfaceFullName, "interface", config.InterfaceFullName, "interface full name, like: go.uber.org/zap/zapcore.Core")
-	interfacer.Flags().StringVar(&newMethod, "method", config.NewMethod, "the method declaration")
-	interfacer.Flags().StringVar(&returnDefaultValues, "returns", config.ReturnDefaultValues, "the return value of the method, like: nil,nil")
+	interfacer.PersistentFlags().StringVar(&yamlFile, "yaml-file", defaultYamlFile, "full project dir")
+	interfacer.PersistentFlags().StringVar(&projectDir, "project-dir", config.ProjectDir, "project dir, the module root found from the working dir by default")
+	interfacer.PersistentFlags().StringVar(&projectModule, "project-module", config.ProjectModule, "project module, read from the go.mod file by default")
+	interfacer.PersistentFlags().StringVar(&interfaceFullName, "interface", config.InterfaceFullName, "interface full name, like: go.uber.org/zap/zapcore.Core, or the short name if it's unambiguous, like: zapcore.Core")
+	interfacer.PersistentFlags().StringVar(&newMethod, "method", config.NewMethod, "the method declaration")
+	interfacer.PersistentFlags().StringVar(&newMethodDoc, "method-doc", config.NewMethodDoc, "the doc comment above the new method of the interface")
+	interfacer.PersistentFlags().StringVar(&implementDoc, "implement-doc", config.ImplementDoc, "the template of the doc comment above the implementations, like: {{.Method}} implements {{.Interface}}.")
+	interfacer.PersistentFlags().StringVar(&returnDefaultValues, "returns", config.ReturnDefaultValues, "the return value of the method, like: nil,nil")
+	interfacer.PersistentFlags().StringSliceVar(&includePackages, "include", config.IncludePackages, "only write the new method to the implements in these packages, like: ./internal/storage/...")
+	interfacer.PersistentFlags().BoolVar(&enableWorkspace, "workspace", config.EnableWorkspace, "scan all modules of the go.work file and the local replace directives as one graph")
+	interfacer.PersistentFlags().BoolVar(&enableNested, "nested-modules", config.EnableNestedModules, "scan the nested modules in the project dir with their own module paths")
+	interfacer.PersistentFlags().BoolVar(&enableVendor, "vendor", config.EnableVendor, "scan the vendor dir read-only to resolve the interfaces and the embedded types")
+	interfacer.PersistentFlags().IntVar(&parallel, "parallel", config.Parallel, "the number of the goroutines parsing the packages, the number of CPUs by default")
+	interfacer.PersistentFlags().BoolVar(&enableCache, "cache", config.EnableCache, "cache the scan results, and only parse the changed files in the next run")
+	interfacer.PersistentFlags().StringVar(&testFiles, "test-files", config.TestFiles, "how to handle the _test.go files: include, exclude or only")
+	interfacer.PersistentFlags().StringVar(&insertPosition, "insert-position", config.InsertPosition, "where the new method is inserted: end, receiver, alphabetical, interface or after:<method>")
+	interfacer.PersistentFlags().StringVar(&writeFile, "write-file", config.WriteFile, "which file the implementations are written to: source, per_type or per_package")
+	interfacer.PersistentFlags().StringVar(&onParseError, "on-parse-error", config.OnParseError, "how to handle the go files which can't be parsed: skip_file, skip_package or fail")
 
 	tool.Info("cmd params", zap.String("yaml-file", yamlFile), zap.String("project_dir", projectDir), zap.String("project_module", projectModule),
 		zap.String("interface_full_name", interfaceFullName), zap.String("method", newMethod),
 		zap.String("return_default_value", returnDefaultValues), zap.Any("config", config))
 }
 
-func readYaml() {
-	defer func() {
-		if e := recover(); e != nil {
-			tool.Info("panic readYaml", zap.Any("err", e))
-		}
-	}()
-
+func readYaml() error {
 	_, err := os.Stat(yamlFile)
-	tool.HandleErrorWithMsg(err, "fail to stat "+yamlFile)
+	if os.IsNotExist(err) {
+		// the default yaml file is optional, and the stdout maybe is the output of the command, like the graph
+		if yamlFile != defaultYamlFile {
+			return tool.NewConfigError("yaml file", yamlFile, "not found the file")
+		}
+		tool.Info("not found the yaml file", zap.String("yaml_file", yamlFile))
+		return nil
+	}
+	if err != nil {
+		return tool.NewConfigError("yaml file", yamlFile, err.Error())
+	}
 
 	f, err := os.Open(yamlFile)
-	tool.HandleErrorWithMsg(err, "fail to open "+yamlFile)
+	if err != nil {
+		return tool.NewConfigError("yaml file", yamlFile, err.Error())
+	}
+	defer f.Close()
+
+	if err = yaml.NewDecoder(f).Decode(config); err != nil && err != io.EOF {
+		return tool.NewConfigError("yaml file", yamlFile, err.Error())
+	}
 
-	err = yaml.NewDecoder(f).Decode(config)
-	tool.HandleErrorWithMsg(err, "fail to decode "+yamlFile)
+	// the relative paths in the yaml file are based on the dir of the yaml file
+	yamlDir, err := filepath.Abs(filepath.Dir(yamlFile))
+	if err != nil {
+		return tool.NewConfigError("yaml file", yamlFile, err.Error())
+	}
+	config.ProjectDir = tool.AbsPath(yamlDir, config.ProjectDir)
+	config.CacheDir = tool.AbsPath(yamlDir, config.CacheDir)
+	config.WritePaths = lo.Map[string, string](config.WritePaths, func(item string, _ int) string {
+		pathInfo := strings.SplitN(item, ",", 2)
+		if len(pathInfo) != 2 {
+			return item
+		}
+		return pathInfo[0] + "," + tool.AbsPath(yamlDir, strings.TrimSpace(pathInfo[1]))
+	})
+	for i := range config.SubModules {
+		config.SubModules[i].ProjectDir = tool.AbsPath(yamlDir, config.SubModules[i].ProjectDir)
+	}
 
 	if projectDir == "" {
 		projectDir = config.ProjectDir
@@ -111,68 +189,371 @@ func readYaml() {
 	if newMethod == "" {
 		newMethod = config.NewMethod
 	}
+	if newMethodDoc == "" {
+		newMethodDoc = config.NewMethodDoc
+	}
+	if implementDoc == "" {
+		implementDoc = config.ImplementDoc
+	}
 	if returnDefaultValues == "" {
 		returnDefaultValues = config.ReturnDefaultValues
 	}
+	if testFiles == "" {
+		testFiles = config.TestFiles
+	}
+	if onParseError == "" {
+		onParseError = config.OnParseError
+	}
+	if insertPosition == "" {
+		insertPosition = config.InsertPosition
+	}
+	if writeFile == "" {
+		writeFile = config.WriteFile
+	}
+	if len(includePackages) == 0 {
+		includePackages = config.IncludePackages
+	}
+	if !enableWorkspace {
+		enableWorkspace = config.EnableWorkspace
+	}
+	if !enableNested {
+		enableNested = config.EnableNestedModules
+	}
+	if !enableVendor {
+		enableVendor = config.EnableVendor
+	}
+	if parallel == 0 {
+		parallel = config.Parallel
+	}
+	if !enableCache {
+		enableCache = config.EnableCache
+	}
+	return nil
+}
+
+// detectProject fill the project dir and module by the `go.mod` file if they are empty
+func detectProject() error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if projectDir == "" {
+		projectDir = tool.FindModuleRoot(wd)
+	}
+	projectDir = tool.AbsPath(wd, projectDir)
+	if projectModule == "" && projectDir != "" {
+		projectModule = tool.ModulePathOfDir(projectDir)
+	}
+	for i, sub := range config.SubModules {
+		if sub.ProjectModule == "" && sub.ProjectDir != "" {
+			config.SubModules[i].ProjectModule = tool.ModulePathOfDir(sub.ProjectDir)
+		}
+		// the sub module only has the import path, find it in the vendor dir or the module cache offline
+		if sub.ProjectDir == "" && sub.ProjectModule != "" {
+			if root := tool.FindModuleRoot(projectDir); root != "" {
+				config.SubModules[i].ProjectDir = tool.ResolveModuleDir(root, sub.ProjectModule)
+			}
+			tool.Info("resolve the sub module dir", zap.String("module", sub.ProjectModule), zap.String("dir", config.SubModules[i].ProjectDir))
+		}
+	}
+	tool.Info("detect project", zap.String("project_dir", projectDir), zap.String("project_module", projectModule))
+	return nil
+}
+
+func cacheDir() string {
+	if config.CacheDir != "" {
+		return config.CacheDir
+	}
+	return scanner.DefaultCacheDir()
+}
+
+func check() error {
+	if err := checkProject(); err != nil {
+		return err
+	}
+	var checker tool.ConfigChecker
+	checker.CheckInterface(interfaceFullName, newMethod, returnDefaultValues)
+	checker.CheckInsertPosition(insertPosition)
+	checker.CheckWriteFile(writeFile)
+	lo.ForEach[SubModule](config.SubModules, func(item SubModule, index int) {
+		checker.CheckInterface(item.InterfaceFullName, item.Method, item.ReturnDefaultValues)
+	})
+	return checker.Err()
 }
 
-func check() {
+// checkProject check the params except the new methods, which are also used by the other commands
+func checkProject() error {
 	var checker tool.ConfigChecker
 	checker.CheckProjectDir(projectDir)
 	checker.CheckModuleName(projectModule)
 	checker.CheckWritePaths(config.WritePaths)
-	checker.CheckInterface(interfaceFullName, newMethod, returnDefaultValues)
+	checker.CheckTestFiles(testFiles)
+	checker.CheckParseErrorMode(onParseError)
+	checker.CheckNamePatterns(config.IgnoreStructs)
 	lo.ForEach[SubModule](config.SubModules, func(item SubModule, index int) {
-		checker.CheckProjectDir(item.ProjectDir)
 		checker.CheckModuleName(item.ProjectModule)
-		checker.CheckInterface(item.InterfaceFullName, item.Method, item.ReturnDefaultValues)
+		checker.CheckSubModuleDir(item.ProjectDir, item.ProjectModule)
 	})
+	return checker.Err()
 }
 
-func implement(cmd *cobra.Command, args []string) {
-	readYaml()
+// prepare read the params and fill the default values, the params are checked by the checkFunc
+func prepare(checkFunc func() error) error {
+	if err := readYaml(); err != nil {
+		return err
+	}
+	if err := detectProject(); err != nil {
+		return err
+	}
+	if testFiles == "" {
+		testFiles = scanner.TestFilesInclude
+	}
+	if onParseError == "" {
+		onParseError = scanner.ParseErrorSkipFile
+	}
 
 	if projectDir == "" || projectModule == "" {
-		tool.HandleErrorWithMsg(errors.New("invalid param"), "The params should be filled")
+		return tool.NewConfigError("project", projectDir, "the project dir and module should be filled, or run it in a go module")
 	}
 
-	check()
+	if err := checkFunc(); err != nil {
+		return err
+	}
 
 	lo.ForEach[string](config.WritePaths, func(item string, index int) {
 		pathInfo := strings.Split(item, ",")
 		writePaths[pathInfo[0]] = pathInfo[1]
 	})
 	ignoreStructs = config.IgnoreStructs
-	config.ExcludeDirs = append(config.ExcludeDirs, []string{".idea", ".git", "vendor", ".github"}...)
+	includePackages = packagePatterns(includePackages)
 	tool.EnableRecord(config.EnableRecord)
 	tool.EnableDebug(config.EnableDebug)
+	return nil
+}
 
-	s := scanner.New(projectModule, projectDir)
-	tool.Timer("Interfacer", func() {
-		s.Start(projectDir, config.ExcludeDirs)
-		s.Print()
-		WriteMethod(s, interfaceFullName, newMethod, returnDefaultValues, false)
+// packagePatterns convert the relative package patterns, which are based on the project module, like: ./internal/storage/...
+func packagePatterns(patterns []string) []string {
+	return lo.Map[string, string](patterns, func(item string, _ int) string {
+		if item == "." || strings.HasPrefix(item, "./") {
+			return projectModule + strings.TrimPrefix(item, ".")
+		}
+		return item
+	})
+}
 
-		for _, sub := range config.SubModules {
-			if sub.InterfaceFullName == "" || sub.Method == "" {
-				continue
-			}
-			subScan := scanner.New(sub.ProjectModule, sub.ProjectDir)
-			subScan.DisableImplementRelation()
-			subScan.Start(sub.ProjectDir, sub.ExcludeDirs)
-			subScan.Print()
-			methodName := sub.Method[:strings.Index(sub.Method, "(")]
-			s.SubModule(subScan, sub.InterfaceFullName, methodName)
-			WriteMethod(s, sub.InterfaceFullName, sub.Method, sub.ReturnDefaultValues, true)
+// loadOptions the options of loading the project by the params
+func loadOptions() api.Options {
+	opts := api.Options{
+		ProjectDir:          projectDir,
+		ProjectModule:       projectModule,
+		ExcludeDirs:         config.ExcludeDirs,
+		ExcludeFiles:        config.ExcludeFiles,
+		IncludeDirs:         config.IncludeDirs,
+		EnableGitignore:     config.EnableGitignore,
+		TestFiles:           testFiles,
+		OnParseError:        onParseError,
+		EnableWorkspace:     enableWorkspace,
+		EnableNestedModules: enableNested,
+		EnableVendor:        enableVendor,
+		Parallel:            parallel,
+		ShowProgress:        !disableProgress,
+	}
+	if enableCache {
+		opts.CacheDir = cacheDir()
+	}
+	return opts
+}
+
+// loadProject load the project by the options, and print the files which can't be parsed
+func loadProject(opts api.Options) (*api.Graph, error) {
+	g, err := api.Load(opts)
+	if err != nil {
+		return nil, err
+	}
+	printDiagnostics(g.Diagnostics())
+	printSkippedModules(g.SkippedModules())
+	return g, nil
+}
+
+// printSkippedModules print the nested modules which aren't scanned to the stderr, because their types can't be found in the project
+func printSkippedModules(modules []*scanner.Module) {
+	if len(modules) == 0 {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "skip the nested modules, use the `--nested-modules` to scan them:")
+	for _, module := range modules {
+		fmt.Fprintln(os.Stderr, "  "+module.Dir+lo.Ternary[string](module.Path == "", "", " ("+module.Path+")"))
+	}
+}
+
+// printDiagnostics print the skipped files to the stderr, so the stdout can still be the output of the command, like the graph
+func printDiagnostics(diagnostics []*tool.ParseError) {
+	if len(diagnostics) == 0 {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "skip the files which can't be parsed:")
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintln(os.Stderr, "  "+diagnostic.Error())
+	}
+}
+
+// loadSubModule scan the sub module, and only the interfaces of it are needed
+func loadSubModule(sub SubModule) (*api.Graph, error) {
+	opts := loadOptions()
+	opts.ProjectDir = sub.ProjectDir
+	opts.ProjectModule = sub.ProjectModule
+	opts.ExcludeDirs = sub.ExcludeDirs
+	opts.ExcludeFiles = sub.ExcludeFiles
+	opts.IncludeDirs = nil
+	opts.EnableGitignore = false
+	opts.EnableWorkspace = false
+	opts.EnableNestedModules = false
+	opts.EnableVendor = false
+	opts.DisableImplement = true
+	return loadProject(opts)
+}
+
+// subModuleGraph the loaded sub module, whose interface is merged into the graph of the project
+type subModuleGraph struct {
+	graph             *api.Graph
+	interfaceFullName string
+}
+
+// loadSubModules load the sub modules having the interface, it's used by the commands only reading the graph
+func loadSubModules() ([]*subModuleGraph, error) {
+	var subs []*subModuleGraph
+	for _, sub := range config.SubModules {
+		if sub.InterfaceFullName == "" {
+			continue
+		}
+		subGraph, err := loadSubModule(sub)
+		if err != nil {
+			return nil, err
+		}
+		name, err := subGraph.Scanner().ResolveInterfaceName(sub.InterfaceFullName)
+		if err != nil {
+			return nil, err
 		}
+		subs = append(subs, &subModuleGraph{graph: subGraph, interfaceFullName: name})
+	}
+	return subs, nil
+}
+
+func mergeSubModules(g *api.Graph, subs []*subModuleGraph) error {
+	for _, sub := range subs {
+		// no method is excluded, because only the real relations are needed
+		if err := g.MergeInterface(sub.graph, sub.interfaceFullName, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadGraph load the project and merge the interfaces of the sub modules, it's used by the commands only reading the graph
+func loadGraph(opts api.Options) (*api.Graph, []*subModuleGraph, error) {
+	g, err := loadProject(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	subs, err := loadSubModules()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = mergeSubModules(g, subs); err != nil {
+		return nil, nil, err
+	}
+	return g, subs, nil
+}
+
+func implement(cmd *cobra.Command, args []string) error {
+	if err := prepare(check); err != nil {
+		return err
+	}
+
+	var err error
+	tool.Timer("Interfacer", func() {
+		err = implementMethods()
 	})
+	return err
+}
+
+// implementMethods add the new method of the project and the sub modules
+func implementMethods() error {
+	g, err := loadProject(loadOptions())
+	if err != nil {
+		return err
+	}
+	if err = WriteMethod(g, interfaceFullName, newMethod, returnDefaultValues, false); err != nil {
+		return err
+	}
+
+	for _, sub := range config.SubModules {
+		if sub.InterfaceFullName == "" || sub.Method == "" {
+			continue
+		}
+		subGraph, err := loadSubModule(sub)
+		if err != nil {
+			return err
+		}
+		if sub.InterfaceFullName, err = subGraph.Scanner().ResolveInterfaceName(sub.InterfaceFullName); err != nil {
+			return err
+		}
+		methodName := sub.Method[:strings.Index(sub.Method, "(")]
+		if err = g.MergeInterface(subGraph, sub.InterfaceFullName, methodName); err != nil {
+			return err
+		}
+		if err = WriteMethod(g, sub.InterfaceFullName, sub.Method, sub.ReturnDefaultValues, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the exit codes of the errors, so the scripts, like the CI, can handle the different failures
+const (
+	exitCodeError     = 1
+	exitCodeConfig    = 2
+	exitCodeParse     = 3
+	exitCodeNotFound  = 4
+	exitCodeAmbiguous = 5
+	exitCodeWrite     = 6
+)
+
+// exitCode the exit code of the error, see the exitCodeXxx
+func exitCode(err error) int {
+	var (
+		configErr    *tool.ConfigError
+		parseErr     *tool.ParseError
+		notFoundErr  *tool.NotFoundError
+		ambiguousErr *tool.AmbiguousError
+		writeErr     *tool.WriteError
+	)
+	switch {
+	case errors.As(err, &configErr):
+		return exitCodeConfig
+	case errors.As(err, &parseErr):
+		return exitCodeParse
+	case errors.As(err, &notFoundErr):
+		return exitCodeNotFound
+	case errors.As(err, &ambiguousErr):
+		return exitCodeAmbiguous
+	case errors.As(err, &writeErr):
+		return exitCodeWrite
+	}
+	return exitCodeError
 }
 
 func main() {
-	if err := interfacer.Execute(); err != nil {
-		if interfacer.SilenceErrors {
-			fmt.Fprintln(os.Stderr, err)
+	// the bug is reported as the exitCodeError, instead of the exit code 2 of the go runtime, which is the exitCodeConfig
+	defer func() {
+		if e := recover(); e != nil {
+			fmt.Fprintf(os.Stderr, "panic: %v\n%s", e, debug.Stack())
+			os.Exit(exitCodeError)
 		}
-		os.Exit(-1)
+	}()
+	if err := interfacer.Execute(); err != nil {
+		tool.Info("fail to execute", zap.Error(err))
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitCode(err))
 	}
 }
@@ -0,0 +1,140 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/SimFG/interfacer/scanner"
+	"github.com/SimFG/interfacer/tool"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+const (
+	graphFormatJSON    = "json"
+	graphFormatDOT     = "dot"
+	graphFormatMermaid = "mermaid"
+)
+
+var graphFormats = []string{graphFormatJSON, graphFormatDOT, graphFormatMermaid}
+
+var (
+	graphCmd = &cobra.Command{
+		Use:   "graph",
+		Short: "Export the interfaces, structs and their relations of the project",
+		RunE:  graph,
+	}
+
+	graphFormat   string
+	graphOutput   string
+	graphRoot     string
+	graphPackages []string
+	graphDepth    int
+)
+
+func init() {
+	interfacer.AddCommand(graphCmd)
+	graphCmd.Flags().StringVar(&graphFormat, "format", graphFormatJSON, "the format of the graph: json, dot or mermaid")
+	graphCmd.Flags().StringVarP(&graphOutput, "output", "o", "", "the file of the graph, the stdout by default")
+	graphCmd.Flags().StringVar(&graphRoot, "root", "", "only the types related to the interface, like: i.Component")
+	graphCmd.Flags().StringSliceVar(&graphPackages, "package", nil, "only the types related to the types in these packages, like: ./internal/storage/...")
+	graphCmd.Flags().IntVar(&graphDepth, "depth", 1, "the max number of the edges from the root interface or the packages, negative means no limit")
+}
+
+func graph(cmd *cobra.Command, args []string) error {
+	// the progress can't be mixed with the graph in the stdout
+	disableProgress = graphOutput == ""
+	err := prepare(func() error {
+		if err := checkProject(); err != nil {
+			return err
+		}
+		var checker tool.ConfigChecker
+		checker.CheckGraphFormat(graphFormat, graphFormats)
+		return checker.Err()
+	})
+	if err != nil {
+		return err
+	}
+
+	g, _, err := loadGraph(loadOptions())
+	if err != nil {
+		return err
+	}
+	s := g.Scanner()
+
+	filtered, err := filterGraph(s, s.Graph())
+	if err != nil {
+		return err
+	}
+	content, err := renderGraph(filtered)
+	if err != nil {
+		return err
+	}
+	if graphOutput == "" {
+		fmt.Print(string(content))
+		return nil
+	}
+	if err = os.WriteFile(graphOutput, content, 0644); err != nil {
+		return &tool.WriteError{File: graphOutput, Err: err}
+	}
+	fmt.Println("write the graph to", graphOutput)
+	return nil
+}
+
+// filterGraph get the sub graph of the root interface and the packages, the whole graph is returned if neither is set
+func filterGraph(s *scanner.Scanner, g *scanner.Graph) (*scanner.Graph, error) {
+	if graphRoot == "" && len(graphPackages) == 0 {
+		return g, nil
+	}
+	var roots []string
+	if graphRoot != "" {
+		root, err := s.ResolveInterfaceName(graphRoot)
+		if err != nil {
+			return nil, err
+		}
+		if !lo.ContainsBy[*scanner.GraphType](g.Interfaces, func(item *scanner.GraphType) bool {
+			return item.Name == root
+		}) {
+			return nil, &tool.NotFoundError{Kind: "root interface", Name: graphRoot}
+		}
+		roots = append(roots, root)
+	}
+	if len(graphPackages) > 0 {
+		matcher, err := tool.NewPackageMatcher(packagePatterns(graphPackages))
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, g.TypesInPackages(matcher.Match)...)
+	}
+	return g.Filter(roots, graphDepth), nil
+}
+
+func renderGraph(g *scanner.Graph) ([]byte, error) {
+	switch graphFormat {
+	case graphFormatDOT:
+		return []byte(g.DOT()), nil
+	case graphFormatMermaid:
+		return []byte(g.Mermaid()), nil
+	}
+	content, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
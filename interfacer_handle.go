@@ -17,79 +17,34 @@
 package main
 
 import (
-	"errors"
+	"fmt"
+	"github.com/SimFG/interfacer/api"
 	"github.com/SimFG/interfacer/scanner"
-	"github.com/SimFG/interfacer/tool"
-	"github.com/SimFG/interfacer/writer"
 	"github.com/samber/lo"
-	"go.uber.org/zap"
-	"strings"
 )
 
-func WriteMethod(s *scanner.Scanner, interfaceFullName string, newMethod string, returnDefaultValues string, skipInterface bool) {
-	interfaceInfo := s.GetInterface(interfaceFullName)
-	if interfaceInfo == nil {
-		tool.HandleErrorWithMsg(errors.New("not found the interface"), "interface name:", interfaceFullName)
-	}
-
-	interfaceName := interfaceFullName[strings.LastIndex(interfaceFullName, ".")+1:]
-	i := strings.Index(newMethod, "(")
-	j := strings.Index(newMethod, ")")
-	x := strings.LastIndex(newMethod, "(")
-	y := strings.LastIndex(newMethod, ")")
-	tool.Info("new method split", zap.Ints("splits", []int{i, j, x, y}))
-
-	var (
-		funcName       string
-		paramNames     []string
-		paramTypes     []string
-		returnTypes    []string
-		returnDefaults []string
-	)
-
-	funcName = newMethod[:i]
-	returnDefaults = strings.Split(returnDefaultValues, ",")
-
-	lo.ForEach[string](strings.Split(newMethod[i+1:j], ","), func(item string, index int) {
-		item = strings.TrimSpace(item)
-		if item == "" {
-			return
-		}
-		paramInfo := strings.Split(item, " ")
-		paramNames = append(paramNames, paramInfo[0])
-		paramTypes = append(paramTypes, paramInfo[1])
+// WriteMethod add the new method to the interface and its implements by the params, and write the changed files
+func WriteMethod(g *api.Graph, interfaceFullName string, newMethod string, returnDefaultValues string, skipInterface bool) error {
+	changes, err := g.AddMethod(interfaceFullName, newMethod, api.AddMethodOptions{
+		ReturnDefaultValues: returnDefaultValues,
+		WritePaths:          writePaths,
+		IgnoreStructs:       ignoreStructs,
+		IncludePackages:     includePackages,
+		TestFilesOnly:       testFiles == scanner.TestFilesOnly,
+		SkipInterface:       skipInterface,
+		InsertPosition:      insertPosition,
+		WriteFile:           writeFile,
+		MethodDoc:           newMethodDoc,
+		ImplementDoc:        implementDoc,
 	})
-	if i == x && j == y {
-		returnType := strings.TrimSpace(newMethod[j+1:])
-		if returnType != "" {
-			returnTypes = append(returnTypes, strings.TrimSpace(newMethod[j+1:]))
-		}
-	} else {
-		lo.ForEach[string](strings.Split(newMethod[x+1:y], ","), func(item string, index int) {
-			item = strings.TrimSpace(item)
-			if item == "" {
-				return
-			}
-			returnTypes = append(returnTypes, item)
-		})
+	if err != nil {
+		return err
 	}
-	tool.Info("method signature", zap.String("func_name", funcName),
-		zap.Strings("param_names", paramNames), zap.Strings("param_types", paramTypes),
-		zap.Strings("return_types", returnTypes), zap.Strings("return_defaults", returnDefaults))
-
-	if !skipInterface {
-		interfaceFileName := interfaceInfo.FilePaths()[0]
-		writer.WriteFileForLine(interfaceFileName, []writer.Writer{writer.GetInterfaceWrite2(interfaceFileName, interfaceName, "\t"+newMethod)})
+	if len(changes.Skipped) > 0 {
+		fmt.Println("skip the implements not in the include packages:")
+		lo.ForEach[string](changes.Skipped, func(item string, _ int) {
+			fmt.Println("  " + item)
+		})
 	}
-	lo.ForEach[*scanner.StructInfo](interfaceInfo.GetImplements(), func(item *scanner.StructInfo, index int) {
-		if lo.Contains(ignoreStructs, item.Name()) {
-			return
-		}
-		writePath := item.FilePaths()[0]
-		if p, ok := writePaths[item.Name()]; ok {
-			writePath = p
-		}
-		receiverName, receiverType := item.MethodReceiver()
-		writer.WriteFile(writePath, []writer.Writer{writer.GetFuncWriter(receiverName, receiverType, funcName, paramNames, paramTypes, returnTypes, returnDefaults)})
-	})
+	return changes.Apply()
 }
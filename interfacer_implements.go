@@ -0,0 +1,59 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+)
+
+var implementsCmd = &cobra.Command{
+	Use:   "implements <struct>",
+	Short: "List the interfaces implemented by the struct, like: interfacer implements s.Node",
+	Args:  cobra.ExactArgs(1),
+	RunE:  implements,
+}
+
+func init() {
+	interfacer.AddCommand(implementsCmd)
+}
+
+func implements(cmd *cobra.Command, args []string) error {
+	if err := prepare(checkProject); err != nil {
+		return err
+	}
+
+	g, _, err := loadGraph(loadOptions())
+	if err != nil {
+		return err
+	}
+	structInfo, err := g.Struct(args[0])
+	if err != nil {
+		return err
+	}
+	structName := structInfo.Name()
+	implementations := g.Scanner().GetImplementations(structName)
+	fmt.Printf("%s implements %d interfaces:\n", structName, len(implementations))
+	for _, implementation := range implementations {
+		receiver := "value and pointer"
+		if !implementation.ByValue {
+			receiver = "pointer only"
+		}
+		fmt.Printf("  %s (%s)\n", implementation.Interface.Name(), receiver)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"github.com/SimFG/interfacer/api"
+	"github.com/SimFG/interfacer/scanner"
+	"github.com/SimFG/interfacer/tool"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultWatchInterval the delay to collect the changed files before rescanning, so saving many files at once causes one rescan
+const defaultWatchInterval = 200 * time.Millisecond
+
+var (
+	watchCmd = &cobra.Command{
+		Use:   "watch",
+		Short: "Keep the type graph live, and print the structs which start or stop implementing the watched interfaces",
+		RunE:  watch,
+	}
+
+	watchInterval time.Duration
+)
+
+func init() {
+	interfacer.AddCommand(watchCmd)
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 0, "the delay to collect the changed files before rescanning, like: 500ms, 200ms by default")
+}
+
+func watch(cmd *cobra.Command, args []string) error {
+	if err := prepare(checkProject); err != nil {
+		return err
+	}
+	if watchInterval == 0 && config.WatchInterval != "" {
+		interval, err := time.ParseDuration(config.WatchInterval)
+		if err != nil {
+			return tool.NewConfigError("watch_interval", config.WatchInterval, err.Error())
+		}
+		watchInterval = interval
+	}
+	if watchInterval <= 0 {
+		watchInterval = defaultWatchInterval
+	}
+
+	opts := loadOptions()
+	opts.EnableWatch = true
+	g, subs, err := loadGraph(opts)
+	if err != nil {
+		return err
+	}
+	s := g.Scanner()
+	if interfaceFullName != "" {
+		if interfaceFullName, err = s.ResolveInterfaceName(interfaceFullName); err != nil {
+			return err
+		}
+	}
+
+	w, err := s.Watch(watchInterval)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	names := watchedInterfaces(subs)
+	if len(names) == 0 {
+		fmt.Println("watch all interfaces, interval:", watchInterval)
+	} else {
+		fmt.Println("watch the interfaces:", strings.Join(names, ", "), "interval:", watchInterval)
+	}
+	last := implementsOf(s, names)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	for {
+		select {
+		case <-stop:
+			fmt.Println("stop watching")
+			return nil
+		case paths := <-w.Events():
+			current, ok := rescan(g, subs, names, paths)
+			if !ok {
+				continue
+			}
+			printImplementsChanges(last, current)
+			last = current
+		}
+	}
+}
+
+// rescan update the packages of the changed paths, the broken file is reported and the last relations are kept
+func rescan(g *api.Graph, subs []*subModuleGraph, names []string, paths []string) (map[string][]string, bool) {
+	changed, err := g.Scanner().RescanPaths(paths)
+	if err == nil && changed {
+		err = mergeSubModules(g, subs)
+	}
+	if err != nil {
+		tool.Warn("fail to rescan", zap.Error(err))
+		fmt.Println("fail to rescan, wait for the next change:", err)
+		return nil, false
+	}
+	if !changed {
+		return nil, false
+	}
+	printDiagnostics(g.Diagnostics())
+	return implementsOf(g.Scanner(), names), true
+}
+
+// watchedInterfaces the interface of the project and the sub modules, all interfaces are watched if it's empty
+func watchedInterfaces(subs []*subModuleGraph) []string {
+	var names []string
+	if interfaceFullName != "" {
+		names = append(names, interfaceFullName)
+	}
+	lo.ForEach[*subModuleGraph](subs, func(item *subModuleGraph, _ int) {
+		names = append(names, item.interfaceFullName)
+	})
+	return lo.Uniq[string](names)
+}
+
+// implementsOf get the sorted struct names implementing the interfaces
+func implementsOf(s *scanner.Scanner, names []string) map[string][]string {
+	if len(names) == 0 {
+		names = s.InterfaceNames()
+	}
+	relations := make(map[string][]string)
+	for _, name := range names {
+		interfaceInfo := s.GetInterface(name)
+		if interfaceInfo == nil {
+			relations[name] = nil
+			continue
+		}
+		relations[name] = lo.Map[*scanner.StructInfo, string](interfaceInfo.GetImplements(), func(item *scanner.StructInfo, _ int) string {
+			return item.Name()
+		})
+	}
+	return relations
+}
+
+func printImplementsChanges(last map[string][]string, current map[string][]string) {
+	names := lo.Uniq[string](append(lo.Keys[string, []string](last), lo.Keys[string, []string](current)...))
+	sort.Strings(names)
+	now := time.Now().Format("15:04:05")
+	for _, name := range names {
+		added, removed := lo.Difference[string](current[name], last[name])
+		lo.ForEach[string](added, func(item string, _ int) {
+			fmt.Printf("[%s] + %s implements %s\n", now, item, name)
+		})
+		lo.ForEach[string](removed, func(item string, _ int) {
+			fmt.Printf("[%s] - %s no longer implements %s\n", now, item, name)
+		})
+	}
+}
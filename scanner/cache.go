@@ -0,0 +1,160 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package scanner
+
+import (
+	"encoding/json"
+	"github.com/SimFG/interfacer/tool"
+	"go.uber.org/zap"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// cacheVersion should be increased when the FileResult is changed
+const cacheVersion = 3
+
+type cacheData struct {
+	Version int
+	Files   map[string]*FileResult
+}
+
+// Cache the FileResult of the files, the key is the file path, and the result is valid if the mtime or content hash isn't changed
+type Cache struct {
+	file  string
+	mu    sync.Mutex
+	files map[string]*FileResult
+	// only the files seen in this run will be saved, the others have been deleted or excluded
+	seen map[string]*FileResult
+}
+
+// NewCache load the cache file of the modules from the cache dir
+func NewCache(dir string, modules []*Module) *Cache {
+	keys := make([]string, 0, len(modules))
+	for _, m := range modules {
+		keys = append(keys, m.Path+"="+m.Dir)
+	}
+	sort.Strings(keys)
+	c := &Cache{
+		file:  tool.PathJoin(dir, tool.Hash([]byte(strings.Join(keys, ";")))[:16]+".json"),
+		files: make(map[string]*FileResult),
+		seen:  make(map[string]*FileResult),
+	}
+
+	content, err := os.ReadFile(c.file)
+	if err != nil {
+		tool.Info("no cache file", zap.String("file", c.file), zap.Error(err))
+		return c
+	}
+	data := &cacheData{}
+	if err = json.Unmarshal(content, data); err != nil || data.Version != cacheVersion {
+		tool.Info("ignore the invalid cache file", zap.String("file", c.file), zap.Error(err))
+		return c
+	}
+	c.files = data.Files
+	tool.Info("load the cache file", zap.String("file", c.file), zap.Int("file_num", len(c.files)))
+	return c
+}
+
+// newMemoryCache the cache isn't saved to the file, it keeps the results between the scans of the watch mode
+func newMemoryCache() *Cache {
+	return &Cache{
+		files: make(map[string]*FileResult),
+		seen:  make(map[string]*FileResult),
+	}
+}
+
+// Begin start a new scan, the results of the last scan are kept to be reused
+func (c *Cache) Begin() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.seen) > 0 {
+		c.files = c.seen
+	}
+	c.seen = make(map[string]*FileResult)
+}
+
+// Get the cached result if the file isn't changed. The content is read only if the mtime is changed, and it's returned for parsing when missing the cache.
+func (c *Cache) Get(path string, info fs.FileInfo) (*FileResult, []byte) {
+	c.mu.Lock()
+	result := c.files[path]
+	c.mu.Unlock()
+
+	if result != nil && result.ModTime == info.ModTime().UnixNano() && result.Size == info.Size() {
+		c.Put(result)
+		return result, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil
+	}
+	if result != nil && result.Hash == tool.Hash(content) {
+		result.ModTime = info.ModTime().UnixNano()
+		result.Size = info.Size()
+		c.Put(result)
+		return result, nil
+	}
+	return nil, content
+}
+
+func (c *Cache) Put(result *FileResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files[result.Path] = result
+	c.seen[result.Path] = result
+}
+
+// Remove forget the result of the deleted or excluded file, it's used by the rescan which doesn't begin a new scan
+func (c *Cache) Remove(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.files, path)
+	delete(c.seen, path)
+}
+
+// Save write the results of the files seen in this run to the cache file
+func (c *Cache) Save() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == "" {
+		return
+	}
+	content, err := json.Marshal(&cacheData{Version: cacheVersion, Files: c.seen})
+	if err != nil {
+		tool.Warn("fail to marshal the cache", zap.Error(err))
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(c.file), 0755); err != nil {
+		tool.Warn("fail to create the cache dir", zap.String("file", c.file), zap.Error(err))
+		return
+	}
+	if err = os.WriteFile(c.file, content, 0644); err != nil {
+		tool.Warn("fail to write the cache file", zap.String("file", c.file), zap.Error(err))
+	}
+}
+
+// DefaultCacheDir the `interfacer` dir in the user cache dir
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return tool.PathJoin(os.TempDir(), "interfacer")
+	}
+	return tool.PathJoin(dir, "interfacer")
+}
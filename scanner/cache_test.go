@@ -0,0 +1,181 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package scanner
+
+import (
+	"github.com/SimFG/interfacer/tool"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// cachedFile write the file and put its result to the cache
+func cachedFile(t *testing.T, c *Cache, path string, content string) {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Put(&FileResult{Path: path, ModTime: info.ModTime().UnixNano(), Size: info.Size(), Hash: tool.Hash([]byte(content))})
+}
+
+func TestCacheGet(t *testing.T) {
+	const content = "package a\n"
+	tests := []struct {
+		name string
+		// change the file or the cache after the result is cached
+		change  func(t *testing.T, c *Cache, path string)
+		wantHit bool
+	}{
+		{name: "unchanged", change: func(t *testing.T, c *Cache, path string) {}, wantHit: true},
+		{name: "touched with the same content", change: func(t *testing.T, c *Cache, path string) {
+			modTime := time.Now().Add(time.Hour)
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+		}, wantHit: true},
+		{name: "changed content", change: func(t *testing.T, c *Cache, path string) {
+			if err := os.WriteFile(path, []byte("package b\n\ntype Foo struct{}\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{name: "changed content with the same size", change: func(t *testing.T, c *Cache, path string) {
+			if err := os.WriteFile(path, []byte("package b\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			modTime := time.Now().Add(time.Hour)
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{name: "removed", change: func(t *testing.T, c *Cache, path string) {
+			c.Remove(path)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "a.go")
+			c := NewCache(t.TempDir(), nil)
+			cachedFile(t, c, path, content)
+			tt.change(t, c, path)
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, src := c.Get(path, info)
+			if !tt.wantHit {
+				current, _ := os.ReadFile(path)
+				if result != nil || string(src) != string(current) {
+					t.Fatalf("want the miss with the content, got the result %v and the content %q", result, src)
+				}
+				return
+			}
+			if result == nil || src != nil {
+				t.Fatalf("want the hit, got the result %v and the content %q", result, src)
+			}
+			// the mtime is updated, so the content isn't read again
+			if result.ModTime != info.ModTime().UnixNano() {
+				t.Errorf("the mtime of the result isn't updated")
+			}
+		})
+	}
+}
+
+func TestCacheSave(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	modules := []*Module{{Path: "github.com/foo", Dir: dir}}
+	a, b := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")
+
+	c := NewCache(cacheDir, modules)
+	cachedFile(t, c, a, "package a\n")
+	cachedFile(t, c, b, "package a\n\ntype Foo struct{}\n")
+	c.Save()
+
+	// the file b isn't seen in the next scan, like the deleted or excluded file
+	c = NewCache(cacheDir, modules)
+	info, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := c.Get(a, info); result == nil {
+		t.Fatalf("want the cached result of %s", a)
+	}
+	c.Save()
+
+	tests := []struct {
+		name    string
+		modules []*Module
+		want    map[string]bool
+	}{
+		{name: "only the seen files are saved", modules: modules, want: map[string]bool{a: true, b: false}},
+		{name: "the other modules use the other file", modules: []*Module{{Path: "github.com/bar", Dir: dir}}, want: map[string]bool{a: false, b: false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(cacheDir, tt.modules)
+			for path, want := range tt.want {
+				info, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result, _ := c.Get(path, info); (result != nil) != want {
+					t.Errorf("the cached result of %s is %v, want %v", path, result != nil, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheVersion(t *testing.T) {
+	dir := t.TempDir()
+	modules := []*Module{{Path: "github.com/foo", Dir: dir}}
+	path := filepath.Join(dir, "a.go")
+	c := NewCache(dir, modules)
+	cachedFile(t, c, path, "package a\n")
+	c.Save()
+
+	tests := []struct {
+		name    string
+		content string
+		wantHit bool
+	}{
+		{name: "valid", wantHit: true},
+		{name: "old version", content: `{"Version":0,"Files":{}}`},
+		{name: "broken file", content: "{"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.content != "" {
+				if err := os.WriteFile(c.file, []byte(tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result, _ := NewCache(dir, modules).Get(path, info); (result != nil) != tt.wantHit {
+				t.Errorf("the cached result is %v, want %v", result != nil, tt.wantHit)
+			}
+		})
+	}
+}
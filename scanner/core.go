@@ -17,6 +17,7 @@
 package scanner
 
 import (
+	"errors"
 	"fmt"
 	"github.com/SimFG/interfacer/progress"
 	"github.com/SimFG/interfacer/tool"
@@ -24,9 +25,15 @@ import (
 	"go.uber.org/zap"
 	"go/parser"
 	"go/token"
+	"golang.org/x/exp/slices"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -45,16 +52,76 @@ func (w *WrapperFunc) Post(structs map[string]*StructInfo, interfaces map[string
 
 type PostParserFunc func(currentName string, innerName string, structs map[string]*StructInfo, interfaces map[string]*InterfaceInfo)
 
+const (
+	// TestFilesInclude scan the `_test.go` files, like the go tool does
+	TestFilesInclude = "include"
+	// TestFilesExclude ignore all `_test.go` files
+	TestFilesExclude = "exclude"
+	// TestFilesOnly scan the `_test.go` files, but only the structs declared in them should receive the new method
+	TestFilesOnly = "only"
+)
+
+const (
+	// ParseErrorSkipFile skip the file which can't be parsed, and the other files of the package are still scanned
+	ParseErrorSkipFile = "skip_file"
+	// ParseErrorSkipPackage skip all files in the dir of the file which can't be parsed
+	ParseErrorSkipPackage = "skip_package"
+	// ParseErrorFail stop the scan, and the Start returns the ParseError
+	ParseErrorFail = "fail"
+)
+
+// Module the go module, the import path of the dir in the module is `Path` + the relative path to `Dir`
+type Module struct {
+	Path string
+	Dir  string
+	// ReadOnly the types in the module are only used to resolve the relations, like the `vendor` dir, and they never receive the new method
+	ReadOnly bool
+}
+
 type Scanner struct {
 	structs         map[string]*StructInfo
 	interfaces      map[string]*InterfaceInfo
-	packageStr      string
-	rootPath        string
+	modules         []*Module
 	enableImplement bool
+	enableGitignore bool
+	enableWatch     bool
+	// enableNested scan the nested modules in the dir with their own module paths, otherwise they are skipped like the go tool
+	enableNested    bool
+	disableProgress bool
+	cacheDir        string
+	cache           *Cache
+	testFiles       string
+	onParseError    string
+	excludeFiles    []string
+	includeDirs     []string
+	matcher         *tool.PathMatcher
+	// includeMatcher the dirs ignored by the go tool, but scanned explicitly
+	includeMatcher  *tool.PathMatcher
 	postParserFuncs []PostParser
+	// dir and excludeDir the args of the Start, they are used to rescan the dir
+	dir        string
+	excludeDir []string
+	// files dir -> the go files of the dir in the last scan, it's used to find the changed packages in the watch mode
+	files map[string]map[string]fs.FileInfo
+	// parseDirs the dirs of the last scan, they are watched in the watch mode
+	parseDirs []string
+	// watcher notify the changed paths, the new dirs are added to it after the rescan
+	watcher *Watcher
+	// diagnostics the files which can't be parsed and are skipped in the last scan
+	diagnostics []*tool.ParseError
+	// skippedModules the nested modules which are skipped in the last scan
+	skippedModules []*Module
+	// tokenIndex method token -> the structs having the method, it's used to find the implements of the interface quickly
+	tokenIndex map[string][]*StructInfo
+	parallel   int
+	// mu protects the structs, interfaces and postParserFuncs when parsing the packages concurrently
+	mu sync.Mutex
+	// packageNames import path -> the name declared by the package clause, it's protected by the namesMu
+	packageNames map[string]string
+	namesMu      sync.Mutex
 
 	fileSum    int
-	currentNum int
+	currentNum int64
 	startTime  time.Time
 	done       chan struct{}
 	lg         *progress.LineGroup
@@ -65,9 +132,12 @@ func New(p string, r string) *Scanner {
 	return &Scanner{
 		structs:         make(map[string]*StructInfo),
 		interfaces:      make(map[string]*InterfaceInfo),
-		packageStr:      p,
-		rootPath:        r,
+		modules:         []*Module{{Path: p, Dir: filepath.Clean(r)}},
 		enableImplement: true,
+		testFiles:       TestFilesInclude,
+		onParseError:    ParseErrorSkipFile,
+		packageNames:    make(map[string]string),
+		parallel:        runtime.NumCPU(),
 		lg:              &progress.LineGroup{},
 		done:            make(chan struct{}),
 	}
@@ -77,22 +147,199 @@ func (s *Scanner) DisableImplementRelation() {
 	s.enableImplement = false
 }
 
-func (s *Scanner) SubModule(sub *Scanner, fullInterfaceName string, method string) {
+// AddModule add the other module, like the member module of the `go.work`, which will be scanned with the root module as one graph
+func (s *Scanner) AddModule(p string, r string) {
+	tool.Info("Scanner AddModule", zap.String("package", p), zap.String("path", r))
+	r = filepath.Clean(r)
+	if lo.ContainsBy[*Module](s.modules, func(item *Module) bool {
+		return item.Dir == r
+	}) {
+		return
+	}
+	s.modules = append(s.modules, &Module{Path: p, Dir: r})
+}
+
+// EnableNestedModules scan the dirs having their own `go.mod` file in the scanned dir, and the module paths of the files are used.
+// They are skipped by default, because they aren't the packages of the module, like the go tool does
+func (s *Scanner) EnableNestedModules() {
+	s.enableNested = true
+}
+
+// nestedModule whether the dir is the root of the nested module, which isn't added to the scanner.
+// The nested module is added if the EnableNestedModules is called.
+func (s *Scanner) nestedModule(dir string) bool {
+	if s.isReadOnly(dir) || lo.ContainsBy[*Module](s.modules, func(item *Module) bool {
+		return item.Dir == dir
+	}) {
+		return false
+	}
+	goMod := tool.PathJoin(dir, tool.GoModFile)
+	if fileInfo, err := os.Stat(goMod); err != nil || fileInfo.IsDir() {
+		return false
+	}
+	module := tool.ReadModulePath(goMod)
+	if !s.enableNested || module == "" {
+		tool.Warn("skip the nested module", zap.String("dir", dir), zap.String("module", module))
+		s.skippedModules = append(s.skippedModules, &Module{Path: module, Dir: dir})
+		return true
+	}
+	s.AddModule(module, dir)
+	return false
+}
+
+// AddVendor add the `vendor` dir as a read-only module, the import path of the dir in it is the relative path to the `vendor` dir
+func (s *Scanner) AddVendor(dir string) {
+	tool.Info("Scanner AddVendor", zap.String("path", dir))
+	s.modules = append(s.modules, &Module{Dir: filepath.Clean(dir), ReadOnly: true})
+}
+
+// moduleOf get the module whose dir is the longest prefix of the dir
+func (s *Scanner) moduleOf(dir string) *Module {
+	var module *Module
+	for _, m := range s.modules {
+		if !isSubDir(m.Dir, dir) {
+			continue
+		}
+		if module == nil || len(m.Dir) > len(module.Dir) {
+			module = m
+		}
+	}
+	return module
+}
+
+// importPath get the import path of the dir by its module
+func (s *Scanner) importPath(dir string) string {
+	module := s.moduleOf(dir)
+	if module == nil {
+		return filepath.ToSlash(dir)
+	}
+	rel := filepath.ToSlash(strings.TrimPrefix(dir[len(module.Dir):], tool.FileSep))
+	if rel == "" || module.Path == "" {
+		return module.Path + rel
+	}
+	return module.Path + "/" + rel
+}
+
+func (s *Scanner) isReadOnly(dir string) bool {
+	module := s.moduleOf(dir)
+	return module != nil && module.ReadOnly
+}
+
+// walkDirs get the dirs that should be walked, the dir, the module dirs outside it and the read-only module dirs
+func (s *Scanner) walkDirs(dir string) []string {
+	dir = filepath.Clean(dir)
+	dirs := []string{dir}
+	for _, m := range s.modules {
+		if !m.ReadOnly && lo.ContainsBy[string](dirs, func(item string) bool {
+			return isSubDir(item, m.Dir)
+		}) {
+			continue
+		}
+		if lo.Contains[string](dirs, m.Dir) {
+			continue
+		}
+		dirs = append(dirs, m.Dir)
+	}
+	return dirs
+}
+
+// isSubDir whether the dir is the parent dir itself or in it
+func isSubDir(parent string, dir string) bool {
+	return dir == parent || strings.HasPrefix(dir, parent+tool.FileSep)
+}
+
+// SetParallel set the number of the goroutines parsing the packages, it's the number of CPUs by default
+func (s *Scanner) SetParallel(n int) {
+	if n > 0 {
+		s.parallel = n
+	}
+}
+
+// DisableProgress don't print the progress, like writing the result to the stdout
+func (s *Scanner) DisableProgress() {
+	s.disableProgress = true
+}
+
+// EnableCache cache the results of the files in the dir, and only the changed files will be parsed in the next run
+func (s *Scanner) EnableCache(dir string) {
+	s.cacheDir = dir
+}
+
+// SetExcludeFiles set the gitignore-style patterns of the files which will be ignored, like `*_mock.go`
+func (s *Scanner) SetExcludeFiles(patterns []string) {
+	s.excludeFiles = patterns
+}
+
+// SetIncludeDirs set the dir names or the gitignore-style patterns of the dirs which are ignored by the go tool but should be scanned,
+// like `testdata` or `_examples`, see ignoredByGo
+func (s *Scanner) SetIncludeDirs(patterns []string) {
+	s.includeDirs = patterns
+}
+
+// EnableGitignore the files and dirs ignored by the `.gitignore` files will be ignored
+func (s *Scanner) EnableGitignore() {
+	s.enableGitignore = true
+}
+
+// addParentIgnoreFiles add the `.gitignore` files from the repository root to the parent of the dir
+func (s *Scanner) addParentIgnoreFiles(dir string) {
+	var parents []string
+	for cur := filepath.Dir(dir); ; cur = filepath.Dir(cur) {
+		parents = append(parents, cur)
+		if _, err := os.Stat(tool.PathJoin(cur, ".git")); err == nil || filepath.Dir(cur) == cur {
+			break
+		}
+	}
+	if _, err := os.Stat(tool.PathJoin(parents[len(parents)-1], ".git")); err != nil {
+		// not in a git repository
+		return
+	}
+	for i := len(parents) - 1; i >= 0; i-- {
+		s.matcher.AddIgnoreFile(tool.PathJoin(parents[i], ".gitignore"))
+	}
+}
+
+// SetTestFiles set how to handle the `_test.go` files, see TestFilesInclude/TestFilesExclude/TestFilesOnly
+func (s *Scanner) SetTestFiles(mode string) {
+	s.testFiles = mode
+}
+
+// SetParseErrorMode set how to handle the files which can't be parsed, see ParseErrorSkipFile/ParseErrorSkipPackage/ParseErrorFail
+func (s *Scanner) SetParseErrorMode(mode string) {
+	s.onParseError = mode
+}
+
+// SkippedModules the nested modules which are skipped in the last scan, see EnableNestedModules
+func (s *Scanner) SkippedModules() []*Module {
+	return append([]*Module(nil), s.skippedModules...)
+}
+
+// Diagnostics the files which can't be parsed and are skipped in the last scan, which are sorted by the paths
+func (s *Scanner) Diagnostics() []*tool.ParseError {
+	diagnostics := append([]*tool.ParseError(nil), s.diagnostics...)
+	sort.Slice(diagnostics, func(i, j int) bool {
+		return diagnostics[i].File < diagnostics[j].File
+	})
+	return diagnostics
+}
+
+// SubModule merge the interface of the sub module, and find its implements in the root module
+func (s *Scanner) SubModule(sub *Scanner, fullInterfaceName string, method string) error {
 	tool.Info("sub module", zap.String("full_interface_name", fullInterfaceName), zap.String("method", method))
 	interfaceInfo, ok := sub.interfaces[fullInterfaceName]
 	if !ok {
-		tool.Panic("not found the interface name in the sub module")
+		return &tool.NotFoundError{Kind: "interface", Name: fullInterfaceName}
 	}
-	if _, ok = s.interfaces[fullInterfaceName]; ok {
-		tool.Panic("found the interface name in the root module")
+	// the interface maybe has been scanned from the read-only vendor dir
+	// the same interface is merged again after the rescan of the watch mode
+	if rootInterfaceInfo, ok := s.interfaces[fullInterfaceName]; ok && rootInterfaceInfo != interfaceInfo && !rootInterfaceInfo.IsReadOnly() {
+		return tool.NewConfigError("sub module interface", fullInterfaceName, "it's found in the root module, please move it to the interface_full_name")
 	}
 	interfaceInfo.ExcludeTokens([]string{method})
-	for _, structInfo := range s.structs {
-		if structInfo.HasImplementInterface(interfaceInfo) {
-			interfaceInfo.structs = append(interfaceInfo.structs, structInfo)
-		}
-	}
+	interfaceInfo.structs = s.findImplements(interfaceInfo)
+	interfaceInfo.resolved = true
 	s.interfaces[fullInterfaceName] = interfaceInfo
+	return nil
 }
 
 func (s *Scanner) GetLineFunc(current int) func(i int, l *progress.Line) {
@@ -105,16 +352,52 @@ func (s *Scanner) GetLineFunc(current int) func(i int, l *progress.Line) {
 	}
 }
 
-func (s *Scanner) Start(dir string, excludeDir []string) {
+// Start scan the dir, it returns the first error of the dirs, like a ParseError
+func (s *Scanner) Start(dir string, excludeDir []string) error {
 	tool.Info("Scanner Start", zap.String("dir", dir), zap.Strings("exclude_dir", excludeDir))
-	excludeDirMap := tool.ToMap(excludeDir)
+	s.dir, s.excludeDir = filepath.Clean(dir), excludeDir
+	s.buildMatcher()
+	walkDirs := s.walkDirs(dir)
+	if !s.disableProgress {
+		s.startProgress(walkDirs)
+		defer func() {
+			s.lg.End(s.GetLineFunc(s.fileSum))
+		}()
+	}
+
+	parseDirs, err := s.collectDirs(walkDirs)
+	if err != nil {
+		close(s.done)
+		return err
+	}
+	s.parseDirs = parseDirs
+	if s.cacheDir != "" {
+		s.cache = NewCache(s.cacheDir, s.modules)
+	} else if s.enableWatch {
+		s.cache = newMemoryCache()
+	}
+	err = s.parse(parseDirs)
+	close(s.done)
+	if err != nil {
+		return err
+	}
+	s.build()
+	return nil
+}
 
-	fmt.Println("start to scan the dir:", dir)
+// startProgress print the cost and the progress of the scan until it's done
+func (s *Scanner) startProgress(walkDirs []string) {
+	fmt.Println("start to scan the dir:", strings.Join(walkDirs, ", "))
 	s.lg.SetLineNum(2)
-	defer func() {
-		s.lg.End(s.GetLineFunc(s.fileSum))
-	}()
-	s.fileSum = tool.FileNumInDir(dir)
+	lo.ForEach[string](walkDirs, func(item string, index int) {
+		// the read-only module dir maybe is in the other walk dir, like the `vendor` dir
+		if lo.ContainsBy[string](walkDirs[:index], func(walkDir string) bool {
+			return isSubDir(walkDir, item)
+		}) {
+			return
+		}
+		s.fileSum += tool.FileNumInDir(item)
+	})
 	s.startTime = time.Now()
 	go func() {
 		for {
@@ -122,78 +405,306 @@ func (s *Scanner) Start(dir string, excludeDir []string) {
 			case <-s.done:
 				return
 			case <-time.After(500 * time.Millisecond):
-				s.lg.Print(s.GetLineFunc(s.currentNum))
+				s.lg.Print(s.GetLineFunc(int(atomic.LoadInt64(&s.currentNum))))
 			}
 		}
 	}()
+}
+
+// buildMatcher build the matcher of the excluded paths in the scanned dir
+func (s *Scanner) buildMatcher() {
+	s.matcher = &tool.PathMatcher{}
+	s.matcher.Add(s.dir, dirPatterns(s.excludeDir)...)
+	s.matcher.Add(s.dir, s.excludeFiles...)
+	if s.enableGitignore {
+		s.addParentIgnoreFiles(s.dir)
+	}
+	s.includeMatcher = &tool.PathMatcher{}
+	s.includeMatcher.Add(s.dir, dirPatterns(s.includeDirs)...)
+}
 
-	tool.FileWalk(dir, true, func(absPath string, fileInfo os.FileInfo) bool {
-		tool.Info("File Walk inner", zap.String("abs_path", absPath))
-		if _, ok := excludeDirMap[fileInfo.Name()]; ok {
-			s.currentNum += tool.FileNumInDir(absPath)
-			return false
+// dirPatterns the exact dir name, like `foo`, only matches the dirs, and the pattern, like `internal/gen/**`, matches all paths
+func dirPatterns(patterns []string) []string {
+	return lo.Map[string, string](patterns, func(item string, _ int) string {
+		if !strings.ContainsAny(item, "/*?[!") {
+			return item + "/"
 		}
-		s.parseDir(absPath)
-		return true
+		return item
 	})
-	close(s.done)
+}
+
+// ignoredByGo whether the dir is ignored by the go tool, like `testdata`, `_examples` and `.cache`, unless it's included explicitly
+func (s *Scanner) ignoredByGo(absPath string) bool {
+	name := filepath.Base(absPath)
+	if name != "testdata" && !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, ".") {
+		return false
+	}
+	return !s.includeMatcher.Match(absPath, true)
+}
 
+// collectDirs walk the dirs serially, because the `.gitignore` files of the parent dirs should be read first
+func (s *Scanner) collectDirs(walkDirs []string) ([]string, error) {
+	var parseDirs []string
+	s.skippedModules = nil
+	for _, walkDir := range walkDirs {
+		err := tool.FileWalk(walkDir, true, func(absPath string, fileInfo os.FileInfo) bool {
+			tool.Info("File Walk inner", zap.String("abs_path", absPath))
+			absPath = filepath.Clean(absPath)
+			if absPath != walkDir && lo.Contains[string](walkDirs, absPath) {
+				// it will be walked by itself
+				return false
+			}
+			if absPath != walkDir && (s.matcher.Match(absPath, true) || s.ignoredByGo(absPath) || s.nestedModule(absPath)) {
+				atomic.AddInt64(&s.currentNum, int64(tool.FileNumInDir(absPath)))
+				return false
+			}
+			if s.enableGitignore {
+				s.matcher.AddIgnoreFile(tool.PathJoin(absPath, ".gitignore"))
+			}
+			parseDirs = append(parseDirs, absPath)
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parseDirs, nil
+}
+
+// parse parse the dirs concurrently, and save the results to the cache.
+// It returns the error of the first dir in order, so the error is the same in every run.
+func (s *Scanner) parse(parseDirs []string) error {
+	if s.cache != nil {
+		s.cache.Begin()
+	}
+	s.diagnostics = nil
+	errs := make([]error, len(parseDirs))
+	tool.ParallelForEach[int](s.parallel, lo.Range(len(parseDirs)), func(i int) {
+		errs[i] = s.parseDir(parseDirs[i])
+	})
+	if s.cache != nil {
+		s.cache.Save()
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// build resolve the embedded types and compute the tokens after all packages are parsed
+func (s *Scanner) build() {
 	lo.ForEach[PostParser](s.postParserFuncs, func(item PostParser, _ int) {
 		item.Post(s.structs, s.interfaces)
 	})
 
-	// TODO more go routine
-	w := sync.WaitGroup{}
-	w.Add(1)
-	go func() {
-		defer w.Done()
-		for _, structInfo := range s.structs {
-			structInfo.Tokens()
-		}
-	}()
+	tool.ParallelForEach[*StructInfo](s.parallel, lo.Values[string, *StructInfo](s.structs), func(item *StructInfo) {
+		item.Tokens()
+	})
+	tool.ParallelForEach[*InterfaceInfo](s.parallel, lo.Values[string, *InterfaceInfo](s.interfaces), func(item *InterfaceInfo) {
+		item.Tokens()
+	})
 
-	w.Add(1)
-	go func() {
-		defer w.Done()
+	if !s.enableImplement {
+		return
+	}
+
+	s.buildTokenIndex()
+	// only the record needs the relations of all interfaces, otherwise they are found when getting the interface
+	if tool.IsRecord() {
 		for _, interfaceInfo := range s.interfaces {
-			interfaceInfo.Tokens()
+			s.resolveImplements(interfaceInfo)
 		}
-	}()
-	w.Wait()
+	}
+}
 
-	if !s.enableImplement {
+func (s *Scanner) buildTokenIndex() {
+	s.tokenIndex = make(map[string][]*StructInfo)
+	for _, structInfo := range s.structs {
+		for _, token := range lo.Uniq[string](structInfo.tokens) {
+			s.tokenIndex[token] = append(s.tokenIndex[token], structInfo)
+		}
+	}
+}
+
+func (s *Scanner) resolveImplements(i *InterfaceInfo) {
+	if i.resolved {
 		return
 	}
+	i.structs = s.findImplements(i)
+	i.resolved = true
+}
 
-	for _, structInfo := range s.structs {
-		for _, interfaceInfo := range s.interfaces {
-			if structInfo.HasImplementInterface(interfaceInfo) {
-				interfaceInfo.structs = append(interfaceInfo.structs, structInfo)
-			}
+// findImplements only check the structs having the rarest method of the interface
+func (s *Scanner) findImplements(i *InterfaceInfo) []*StructInfo {
+	if s.tokenIndex == nil {
+		s.buildTokenIndex()
+	}
+	var candidates []*StructInfo
+	rarest := ""
+	for _, token := range i.tokens {
+		if slices.Contains(i.excludeTokens, token) {
+			continue
+		}
+		if rarest == "" || len(s.tokenIndex[token]) < len(s.tokenIndex[rarest]) {
+			rarest = token
 		}
 	}
+	if rarest == "" {
+		// all structs implement the empty interface
+		candidates = lo.Values[string, *StructInfo](s.structs)
+	} else {
+		candidates = s.tokenIndex[rarest]
+	}
+
+	implements := lo.Filter[*StructInfo](candidates, func(item *StructInfo, _ int) bool {
+		return item.HasImplementInterface(i)
+	})
+	sort.Slice(implements, func(x, y int) bool {
+		return implements[x].name < implements[y].name
+	})
+	return implements
 }
 
 func (s *Scanner) parseDir(dir string) error {
 	tool.Info("Scanner parseDir", zap.String("dir", dir))
 
-	fs := token.NewFileSet()
-	result, err := parser.ParseDir(fs, dir, nil, 0)
-	tool.HandleErrorWithMsg(err, "fail to parse dir:", dir)
+	// package name -> the results of the files
+	packages := make(map[string][]*FileResult)
+	infos, err := s.goFiles(dir)
+	if err != nil {
+		return err
+	}
+	if s.enableWatch {
+		files := make(map[string]fs.FileInfo)
+		for _, info := range infos {
+			files[tool.PathJoin(dir, info.Name())] = info
+		}
+		s.mu.Lock()
+		s.files[dir] = files
+		s.mu.Unlock()
+	}
+	broken := false
+	for _, info := range infos {
+		path := tool.PathJoin(dir, info.Name())
+		result, err := s.parseFile(path, info)
+		var parseErr *tool.ParseError
+		if errors.As(err, &parseErr) && s.onParseError != ParseErrorFail {
+			tool.Warn("skip the file which can't be parsed", zap.String("file", path), zap.Error(err))
+			s.mu.Lock()
+			s.diagnostics = append(s.diagnostics, parseErr)
+			s.mu.Unlock()
+			atomic.AddInt64(&s.currentNum, 1)
+			broken = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		packages[result.PackageName] = append(packages[result.PackageName], result)
+	}
+	if broken && s.onParseError == ParseErrorSkipPackage {
+		tool.Warn("skip the package having the file which can't be parsed", zap.String("dir", dir))
+		for _, results := range packages {
+			atomic.AddInt64(&s.currentNum, int64(len(results)))
+		}
+		return nil
+	}
 
-	for _, r := range result {
+	for name, results := range packages {
 		lastSep := strings.LastIndex(dir, tool.FileSep)
 		lastWord := dir[lastSep+1:]
-		curPackage := strings.Replace(dir, s.rootPath, s.packageStr, 1)
-		if lastWord != r.Name {
-			tool.Info("WARN package name is uncommon", zap.String("dir", dir), zap.String("package", r.Name))
+		curPackage := s.importPath(filepath.Clean(dir))
+		packageName := name
+		// the external test package, like `package foo_test`, has its own import path
+		if isExternalTestPackage(name, results) {
+			curPackage += "_test"
+			packageName = strings.TrimSuffix(packageName, "_test")
+		}
+		if lastWord != packageName {
+			tool.Info("WARN package name is uncommon", zap.String("dir", dir), zap.String("package", name))
 		}
-		p := NewPackageParser(s, curPackage, dir, r)
+		s.setPackageName(curPackage, name)
+		p := NewPackageParser(s, curPackage, dir, results)
 		p.Parse()
 	}
 	return nil
 }
 
+// goFiles get the go files in the dir which aren't excluded
+func (s *Scanner) goFiles(dir string) ([]fs.FileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []fs.FileInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		if !s.filterFile(dir, info) {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (s *Scanner) filterFile(dir string, info fs.FileInfo) bool {
+	if s.matcher != nil && s.matcher.Match(tool.PathJoin(dir, info.Name()), false) {
+		tool.Info("exclude the file", zap.String("dir", dir), zap.String("file", info.Name()))
+		return false
+	}
+	return s.testFiles != TestFilesExclude || !tool.IsTestFile(info.Name())
+}
+
+// parseFile get the result of the file from the cache, or parse the file if it has been changed
+func (s *Scanner) parseFile(path string, info fs.FileInfo) (*FileResult, error) {
+	var content []byte
+	if s.cache != nil {
+		var result *FileResult
+		if result, content = s.cache.Get(path, info); result != nil {
+			tool.Info("hit the cache", zap.String("file", path))
+			return result, nil
+		}
+	}
+	if content == nil {
+		var err error
+		if content, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, path, content, 0)
+	if err != nil {
+		return nil, tool.NewParseError(path, err)
+	}
+	result := NewFileResult(fset, path, astFile)
+	result.ModTime = info.ModTime().UnixNano()
+	result.Size = info.Size()
+	result.Hash = tool.Hash(content)
+	if s.cache != nil {
+		s.cache.Put(result)
+	}
+	return result, nil
+}
+
+func isExternalTestPackage(name string, results []*FileResult) bool {
+	if !strings.HasSuffix(name, "_test") {
+		return false
+	}
+	return lo.EveryBy[*FileResult](results, func(item *FileResult) bool {
+		return tool.IsTestFile(item.Path)
+	})
+}
+
 func (s *Scanner) Print() {
 	for _, info := range s.structs {
 		info.Print()
@@ -204,10 +715,85 @@ func (s *Scanner) Print() {
 	}
 }
 
+// ResolveInterfaceName convert the short interface name, like `pkg.Name`, to the full name.
+// It returns the name directly if it's a full name, an unqualified `Name` or no interface matches it, and an AmbiguousError if it's ambiguous.
+// The unqualified name isn't matched, because it maybe picks the interface of the other package silently.
+func (s *Scanner) ResolveInterfaceName(name string) (string, error) {
+	return resolveTypeName[*InterfaceInfo](name, s.interfaces, "interface")
+}
+
+// ResolveStructName convert the short struct name to the full name, like the ResolveInterfaceName
+func (s *Scanner) ResolveStructName(name string) (string, error) {
+	return resolveTypeName[*StructInfo](name, s.structs, "struct")
+}
+
+// resolveTypeName the short name is matched by the declared package name and the last element of the package path
+func resolveTypeName[T interface{ ShortName() string }](name string, types map[string]T, kind string) (string, error) {
+	if _, ok := types[name]; ok || strings.Contains(name, "/") || !strings.Contains(name, ".") {
+		return name, nil
+	}
+	var candidates []string
+	for fullName, info := range types {
+		if shortTypeName(fullName) == name || info.ShortName() == name {
+			candidates = append(candidates, fullName)
+		}
+	}
+	if len(candidates) > 1 {
+		sort.Strings(candidates)
+		return "", &tool.AmbiguousError{Kind: kind + " name", Name: name, Candidates: candidates}
+	}
+	if len(candidates) == 1 {
+		tool.Info("resolve the "+kind+" name", zap.String("name", name), zap.String("full_name", candidates[0]))
+		return candidates[0], nil
+	}
+	return name, nil
+}
+
+// shortTypeName get the `pkg.Name` from the full name, like: github.com/foo/pkg.Name -> pkg.Name
+func shortTypeName(fullName string) string {
+	return fullName[strings.LastIndex(fullName, "/")+1:]
+}
+
 func (s *Scanner) GetInterface(name string) *InterfaceInfo {
 	interfaceInfo := s.interfaces[name]
 	if interfaceInfo != nil {
+		if s.enableImplement {
+			s.resolveImplements(interfaceInfo)
+		}
 		interfaceInfo.Print()
 	}
 	return interfaceInfo
 }
+
+func (s *Scanner) GetStruct(name string) *StructInfo {
+	return s.structs[name]
+}
+
+// Implementation the interface implemented by the struct
+type Implementation struct {
+	Interface *InterfaceInfo
+	// ByValue whether the value of the struct implements the interface, otherwise only the pointer implements it
+	ByValue bool
+}
+
+// GetImplementations get the interfaces implemented by the struct, including the interfaces merged from the sub modules, which are sorted by the names
+func (s *Scanner) GetImplementations(structName string) []*Implementation {
+	structInfo := s.structs[structName]
+	if structInfo == nil {
+		return nil
+	}
+	var implementations []*Implementation
+	for _, interfaceInfo := range s.interfaces {
+		if !structInfo.HasImplementInterface(interfaceInfo) {
+			continue
+		}
+		implementations = append(implementations, &Implementation{
+			Interface: interfaceInfo,
+			ByValue:   structInfo.HasImplementInterfaceByValue(interfaceInfo),
+		})
+	}
+	sort.Slice(implementations, func(i, j int) bool {
+		return implementations[i].Interface.name < implementations[j].Interface.name
+	})
+	return implementations
+}
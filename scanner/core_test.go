@@ -0,0 +1,510 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package scanner
+
+import (
+	"errors"
+	"github.com/SimFG/interfacer/tool"
+	"github.com/samber/lo"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// writeFiles write the files to the dir and return their paths, the empty content means removing the path
+func writeFiles(t *testing.T, dir string, files map[string]string) []string {
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		paths = append(paths, path)
+		if content == "" {
+			if err := os.RemoveAll(path); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+// implementNames get the sorted names of the implements of the interface
+func implementNames(s *Scanner, interfaceName string) []string {
+	interfaceInfo := s.GetInterface(interfaceName)
+	if interfaceInfo == nil {
+		return nil
+	}
+	names := lo.Map[*StructInfo, string](interfaceInfo.GetImplements(), func(item *StructInfo, _ int) string {
+		return item.Name()
+	})
+	sort.Strings(names)
+	return names
+}
+
+func TestTestFiles(t *testing.T) {
+	files := map[string]string{
+		"a/a.go":        "package a\n\ntype Closer interface {\n\tClose() error\n}\n\ntype Foo struct{}\n\nfunc (f *Foo) Close() error {\n\treturn nil\n}\n",
+		"a/a_test.go":   "package a\n\ntype fakeCloser struct{}\n\nfunc (f *fakeCloser) Close() error {\n\treturn nil\n}\n",
+		"a/ext_test.go": "package a_test\n\ntype extCloser struct{}\n\nfunc (e extCloser) Close() error {\n\treturn nil\n}\n",
+	}
+	all := []string{"github.com/foo/a.Foo", "github.com/foo/a.fakeCloser", "github.com/foo/a_test.extCloser"}
+	tests := []struct {
+		mode           string
+		wantImplements []string
+		wantTestOnly   []string
+	}{
+		{mode: TestFilesInclude, wantImplements: all, wantTestOnly: all[1:]},
+		{mode: TestFilesExclude, wantImplements: all[:1]},
+		// the test files are scanned like the include mode, and the caller only writes to the test only structs
+		{mode: TestFilesOnly, wantImplements: all, wantTestOnly: all[1:]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, files)
+			s := New("github.com/foo", dir)
+			s.SetTestFiles(tt.mode)
+			if err := s.Start(dir, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			implements := implementNames(s, "github.com/foo/a.Closer")
+			if !reflect.DeepEqual(implements, tt.wantImplements) {
+				t.Errorf("the implements are %v, want %v", implements, tt.wantImplements)
+			}
+			testOnly := lo.Filter[string](implements, func(item string, _ int) bool {
+				return s.structs[item].IsTestOnly()
+			})
+			if len(testOnly) == 0 {
+				testOnly = nil
+			}
+			if !reflect.DeepEqual(testOnly, tt.wantTestOnly) {
+				t.Errorf("the test only structs are %v, want %v", testOnly, tt.wantTestOnly)
+			}
+		})
+	}
+}
+
+func TestResolveInterfaceName(t *testing.T) {
+	files := map[string]string{
+		"a/a.go":   "package a\n\ntype Closer interface {\n\tClose() error\n}\n",
+		"b/b.go":   "package b\n\ntype Reader interface {\n\tRead() error\n}\n",
+		"x/a/a.go": "package a\n\ntype Closer interface {\n\tClose() error\n}\n",
+	}
+	tests := []struct {
+		name      string
+		want      string
+		ambiguous bool
+	}{
+		{name: "github.com/foo/b.Reader", want: "github.com/foo/b.Reader"},
+		{name: "b.Reader", want: "github.com/foo/b.Reader"},
+		{name: "a.Closer", ambiguous: true},
+		// the unqualified name isn't matched with the type of any package
+		{name: "Reader", want: "Reader"},
+		{name: "b.Missing", want: "b.Missing"},
+		{name: "example.com/c.Reader", want: "example.com/c.Reader"},
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, files)
+	s := New("github.com/foo", dir)
+	if err := s.Start(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ResolveInterfaceName(tt.name)
+			var ambiguousErr *tool.AmbiguousError
+			if errors.As(err, &ambiguousErr) != tt.ambiguous {
+				t.Fatalf("got the error %v, want the ambiguous error: %v", err, tt.ambiguous)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveInterfaceName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModules(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"root/i/i.go":     "package i\n\ntype Closer interface {\n\tClose() error\n}\n",
+		"root/a/a.go":     "package a\n\ntype Foo struct{}\n\nfunc (f Foo) Close() error {\n\treturn nil\n}\n",
+		"root/inner/b.go": "package inner\n\ntype Bar struct{}\n\nfunc (b Bar) Close() error {\n\treturn nil\n}\n",
+		"lib/c/c.go":      "package c\n\ntype Baz struct{}\n\nfunc (b Baz) Close() error {\n\treturn nil\n}\n",
+	})
+	root := filepath.Join(dir, "root")
+	s := New("github.com/foo", root)
+	// the module outside the root dir is walked too, and the module in the root dir has its own import path
+	s.AddModule("github.com/lib", filepath.Join(dir, "lib"))
+	s.AddModule("example.com/inner", filepath.Join(root, "inner"))
+	s.AddModule("github.com/lib", filepath.Join(dir, "lib"))
+	if len(s.modules) != 3 {
+		t.Errorf("got %d modules, the duplicated module should be ignored", len(s.modules))
+	}
+	s.Start(root, nil)
+
+	want := []string{"example.com/inner.Bar", "github.com/foo/a.Foo", "github.com/lib/c.Baz"}
+	if got := implementNames(s, "github.com/foo/i.Closer"); !reflect.DeepEqual(got, want) {
+		t.Errorf("the implements are %v, want %v", got, want)
+	}
+}
+
+func TestVendor(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"vendor/github.com/dep/d/d.go": "package d\n\ntype Closer interface {\n\tClose() error\n}\n\ntype Base struct{}\n\nfunc (b *Base) Close() error {\n\treturn nil\n}\n",
+		"a/a.go":                       "package a\n\nimport \"github.com/dep/d\"\n\ntype Foo struct {\n\t*d.Base\n}\n",
+	})
+	s := New("github.com/foo", dir)
+	s.AddVendor(filepath.Join(dir, "vendor"))
+	if err := s.Start(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the embedded type of the vendor dir is resolved, and the vendor types are read-only
+	want := []string{"github.com/dep/d.Base", "github.com/foo/a.Foo"}
+	if got := implementNames(s, "github.com/dep/d.Closer"); !reflect.DeepEqual(got, want) {
+		t.Errorf("the implements are %v, want %v", got, want)
+	}
+	for name, info := range s.structs {
+		if want := name == "github.com/dep/d.Base"; info.IsReadOnly() != want {
+			t.Errorf("the read-only of %s is %v, want %v", name, info.IsReadOnly(), want)
+		}
+	}
+	if !s.GetInterface("github.com/dep/d.Closer").IsReadOnly() {
+		t.Errorf("the vendor interface should be read-only")
+	}
+}
+
+func TestPackagePath(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a/b/b.go":      "package b\n\ntype Foo struct{}\n",
+		"a/b/b_test.go": "package b_test\n\ntype fakeFoo struct{}\n",
+	})
+	s := New("github.com/foo", dir)
+	if err := s.Start(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"github.com/foo/a/b.Foo":          "github.com/foo/a/b",
+		"github.com/foo/a/b_test.fakeFoo": "github.com/foo/a/b_test",
+	}
+	for name, path := range want {
+		info := s.structs[name]
+		if info == nil {
+			t.Errorf("not found the struct %s", name)
+			continue
+		}
+		if info.PackagePath() != path {
+			t.Errorf("the package path of %s is %s, want %s", name, info.PackagePath(), path)
+		}
+	}
+}
+
+func TestParallel(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"i/i.go": "package i\n\ntype Closer interface {\n\tClose() error\n}\n"}
+	var want []string
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		files[name+"/"+name+".go"] = "package " + name + "\n\ntype Foo struct{}\n\nfunc (f Foo) Close() error {\n\treturn nil\n}\n"
+		want = append(want, "github.com/foo/"+name+".Foo")
+	}
+	writeFiles(t, dir, files)
+	for _, n := range []int{1, 4} {
+		s := New("github.com/foo", dir)
+		s.SetParallel(n)
+		if err := s.Start(dir, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := implementNames(s, "github.com/foo/i.Closer"); !reflect.DeepEqual(got, want) {
+			t.Errorf("the implements of the parallel %d are %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestLazyImplements(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"i/i.go": "package i\n\ntype Empty interface{}\n\ntype Closer interface {\n\tClose() error\n}\n\n" +
+			"type ReadCloser interface {\n\tCloser\n\tRead() error\n}\n",
+		"a/a.go": "package a\n\ntype Foo struct{}\n\nfunc (f Foo) Close() error {\n\treturn nil\n}\n\n" +
+			"type Bar struct{}\n\nfunc (b Bar) Close() error {\n\treturn nil\n}\n\nfunc (b Bar) Read() error {\n\treturn nil\n}\n\n" +
+			"type Baz struct{}\n",
+	})
+	cases := []struct {
+		name          string
+		interfaceName string
+		exclude       []string
+		want          []string
+	}{
+		{"one method", "github.com/foo/i.Closer", nil, []string{"github.com/foo/a.Bar", "github.com/foo/a.Foo"}},
+		{"embedded interface", "github.com/foo/i.ReadCloser", nil, []string{"github.com/foo/a.Bar"}},
+		{"excluded method", "github.com/foo/i.ReadCloser", []string{"Close"}, []string{"github.com/foo/a.Bar"}},
+		{"empty interface", "github.com/foo/i.Empty", nil,
+			[]string{"github.com/foo/a.Bar", "github.com/foo/a.Baz", "github.com/foo/a.Foo"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := New("github.com/foo", dir)
+			if err := s.Start(dir, nil); err != nil {
+				t.Fatal(err)
+			}
+			interfaceInfo := s.interfaces[c.interfaceName]
+			if interfaceInfo == nil {
+				t.Fatalf("not found the interface %s", c.interfaceName)
+			}
+			if interfaceInfo.resolved {
+				t.Fatalf("the implements of %s are resolved before getting the interface", c.interfaceName)
+			}
+			interfaceInfo.ExcludeTokens(c.exclude)
+			if got := implementNames(s, c.interfaceName); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("the implements are %v, want %v", got, c.want)
+			}
+			if !interfaceInfo.resolved {
+				t.Errorf("the implements of %s aren't resolved after getting the interface", c.interfaceName)
+			}
+		})
+	}
+}
+
+func TestTokenIndex(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a/a.go": "package a\n\ntype Foo struct{}\n\nfunc (f Foo) Close() error {\n\treturn nil\n}\n\n" +
+			"type Bar struct {\n\tFoo\n}\n\nfunc (b *Bar) Read() error {\n\treturn nil\n}\n",
+	})
+	s := New("github.com/foo", dir)
+	if err := s.Start(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"Close[][error]": {"github.com/foo/a.Bar", "github.com/foo/a.Foo"},
+		"Read[][error]":  {"github.com/foo/a.Bar"},
+	}
+	if len(s.tokenIndex) != len(want) {
+		t.Errorf("the token index has %d tokens, want %d", len(s.tokenIndex), len(want))
+	}
+	for token, names := range want {
+		got := lo.Map[*StructInfo, string](s.tokenIndex[token], func(item *StructInfo, _ int) string {
+			return item.Name()
+		})
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, names) {
+			t.Errorf("the structs of the token %s are %v, want %v", token, got, names)
+		}
+	}
+}
+
+func TestGetImplementations(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"i/i.go": "package i\n\ntype Closer interface {\n\tClose() error\n}\n\n" +
+			"type ReadCloser interface {\n\tCloser\n\tRead() error\n}\n",
+		"a/a.go": "package a\n\n" +
+			"type Value struct{}\n\nfunc (v Value) Close() error {\n\treturn nil\n}\n\nfunc (v Value) Read() error {\n\treturn nil\n}\n\n" +
+			"type Pointer struct{}\n\nfunc (p *Pointer) Close() error {\n\treturn nil\n}\n\nfunc (p Pointer) Read() error {\n\treturn nil\n}\n\n" +
+			"type EmbedValue struct {\n\tPointer\n}\n\n" +
+			"type EmbedPointer struct {\n\t*Pointer\n}\n\n" +
+			"type None struct{}\n",
+	})
+	s := New("github.com/foo", dir)
+	s.DisableProgress()
+	if err := s.Start(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	const closer, readCloser = "github.com/foo/i.Closer", "github.com/foo/i.ReadCloser"
+	tests := []struct {
+		name string
+		// want interface name -> whether the value implements it
+		want map[string]bool
+	}{
+		{name: "github.com/foo/a.Value", want: map[string]bool{closer: true, readCloser: true}},
+		{name: "github.com/foo/a.Pointer", want: map[string]bool{closer: false, readCloser: false}},
+		{name: "github.com/foo/a.EmbedValue", want: map[string]bool{closer: false, readCloser: false}},
+		{name: "github.com/foo/a.EmbedPointer", want: map[string]bool{closer: true, readCloser: true}},
+		{name: "github.com/foo/a.None", want: map[string]bool{}},
+		{name: "github.com/foo/a.Missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			implementations := s.GetImplementations(tt.name)
+			var got map[string]bool
+			if implementations != nil || tt.want != nil {
+				got = make(map[string]bool)
+			}
+			for _, item := range implementations {
+				got[item.Interface.Name()] = item.ByValue
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("the implementations are %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrorMode(t *testing.T) {
+	files := map[string]string{
+		"i/i.go":      "package i\n\ntype Closer interface {\n\tClose() error\n}\n",
+		"a/a.go":      "package a\n\ntype Foo struct{}\n\nfunc (f Foo) Close() error {\n\treturn nil\n}\n",
+		"a/broken.go": "package a\n\nfunc (\n",
+		"b/b.go":      "package b\n\ntype Bar struct{}\n\nfunc (b Bar) Close() error {\n\treturn nil\n}\n",
+	}
+	tests := []struct {
+		mode            string
+		wantErr         bool
+		wantImplements  []string
+		wantDiagnostics []string
+	}{
+		{mode: ParseErrorSkipFile, wantImplements: []string{"github.com/foo/a.Foo", "github.com/foo/b.Bar"}, wantDiagnostics: []string{"a/broken.go"}},
+		{mode: ParseErrorSkipPackage, wantImplements: []string{"github.com/foo/b.Bar"}, wantDiagnostics: []string{"a/broken.go"}},
+		{mode: ParseErrorFail, wantErr: true},
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, files)
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			s := New("github.com/foo", dir)
+			s.DisableProgress()
+			s.SetParseErrorMode(tt.mode)
+			err := s.Start(dir, nil)
+			var parseErr *tool.ParseError
+			if errors.As(err, &parseErr) != tt.wantErr {
+				t.Fatalf("got the error %v, want the parse error: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := implementNames(s, "github.com/foo/i.Closer"); !reflect.DeepEqual(got, tt.wantImplements) {
+				t.Errorf("the implements are %v, want %v", got, tt.wantImplements)
+			}
+			diagnostics := lo.Map[*tool.ParseError, string](s.Diagnostics(), func(item *tool.ParseError, _ int) string {
+				rel, _ := filepath.Rel(dir, item.File)
+				return filepath.ToSlash(rel)
+			})
+			if !reflect.DeepEqual(diagnostics, tt.wantDiagnostics) {
+				t.Errorf("the diagnostics are %v, want %v", diagnostics, tt.wantDiagnostics)
+			}
+		})
+	}
+}
+
+func TestIgnoredDirs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a/a.go":             "package a\n\ntype Foo struct{}\n",
+		"a/testdata/t.go":    "package testdata\n\ntype Fixture struct{}\n",
+		"_examples/e/e.go":   "package e\n\ntype Example struct{}\n",
+		".cache/c/c.go":      "package c\n\ntype Cached struct{}\n",
+		"b/_internal/i/i.go": "package i\n\ntype Hidden struct{}\n",
+	})
+	tests := []struct {
+		name        string
+		includeDirs []string
+		want        []string
+	}{
+		{name: "ignored by default", want: []string{"github.com/foo/a.Foo"}},
+		{name: "include the dir name", includeDirs: []string{"testdata"}, want: []string{"github.com/foo/a.Foo", "github.com/foo/a/testdata.Fixture"}},
+		{name: "include the path", includeDirs: []string{"b/_internal"}, want: []string{"github.com/foo/a.Foo", "github.com/foo/b/_internal/i.Hidden"}},
+		{name: "include many dirs", includeDirs: []string{".cache", "_examples"},
+			want: []string{"github.com/foo/.cache/c.Cached", "github.com/foo/_examples/e.Example", "github.com/foo/a.Foo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New("github.com/foo", dir)
+			s.DisableProgress()
+			s.SetIncludeDirs(tt.includeDirs)
+			if err := s.Start(dir, nil); err != nil {
+				t.Fatal(err)
+			}
+			got := lo.Keys[string, *StructInfo](s.structs)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("the structs are %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNestedModules(t *testing.T) {
+	files := map[string]string{
+		"go.mod":          "module github.com/foo\n\ngo 1.18\n",
+		"a.go":            "package foo\n\ntype Foo struct{}\n",
+		"sub/go.mod":      "module github.com/foo/sub\n\ngo 1.18\n",
+		"sub/b.go":        "package sub\n\ntype Bar struct{}\n",
+		"broken/go.mod":   "go 1.18\n",
+		"broken/c.go":     "package broken\n\ntype Baz struct{}\n",
+		"nested/d/go.mod": "module example.com/d\n",
+		"nested/d/d.go":   "package d\n\ntype Qux struct{}\n",
+	}
+	tests := []struct {
+		name        string
+		enable      bool
+		wantStructs []string
+		wantSkipped []string
+	}{
+		{
+			name:        "skipped by default",
+			wantStructs: []string{"github.com/foo.Foo"},
+			wantSkipped: []string{"broken", "nested/d", "sub"},
+		},
+		{
+			name:        "enabled",
+			enable:      true,
+			wantStructs: []string{"github.com/foo.Foo", "github.com/foo/sub.Bar", "example.com/d.Qux"},
+			wantSkipped: []string{"broken"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, files)
+			s := New("github.com/foo", dir)
+			s.DisableProgress()
+			if tt.enable {
+				s.EnableNestedModules()
+			}
+			if err := s.Start(dir, nil); err != nil {
+				t.Fatal(err)
+			}
+			if len(s.structs) != len(tt.wantStructs) {
+				t.Errorf("got %d structs, want %v", len(s.structs), tt.wantStructs)
+			}
+			for _, name := range tt.wantStructs {
+				if _, ok := s.structs[name]; !ok {
+					t.Errorf("want the struct %s", name)
+				}
+			}
+			skipped := s.SkippedModules()
+			if len(skipped) != len(tt.wantSkipped) {
+				t.Fatalf("got %d skipped modules, want %v", len(skipped), tt.wantSkipped)
+			}
+			for i, module := range skipped {
+				if want := filepath.Join(dir, filepath.FromSlash(tt.wantSkipped[i])); module.Dir != want {
+					t.Errorf("skipped module %d is %s, want %s", i, module.Dir, want)
+				}
+			}
+		})
+	}
+}
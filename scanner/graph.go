@@ -0,0 +1,217 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package scanner
+
+import (
+	"github.com/samber/lo"
+	"sort"
+	"strings"
+)
+
+// GraphVersion the version of the Graph document, it should be increased when the existing fields are changed or removed
+const GraphVersion = 1
+
+const (
+	// EdgeEmbed the struct or interface embeds the other struct or interface
+	EdgeEmbed = "embed"
+	// EdgeImplement the struct implements the interface
+	EdgeImplement = "implement"
+)
+
+// Graph the stable document of all interfaces, structs and their relations, the items are sorted by the names
+type Graph struct {
+	Version    int          `json:"version"`
+	Interfaces []*GraphType `json:"interfaces"`
+	Structs    []*GraphType `json:"structs"`
+	Edges      []*GraphEdge `json:"edges"`
+}
+
+// GraphType the interface or struct
+type GraphType struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	// PackageName the name declared by the package clause, it may be different from the last element of the Package
+	PackageName string         `json:"package_name"`
+	Position    *GraphPosition `json:"position,omitempty"`
+	Files       []string       `json:"files"`
+	ReadOnly    bool           `json:"read_only,omitempty"`
+	Methods     []*GraphMethod `json:"methods"`
+}
+
+// GraphMethod the method declared by the interface or struct, the embedded methods aren't included
+type GraphMethod struct {
+	Name      string         `json:"name"`
+	Signature string         `json:"signature"`
+	Params    []string       `json:"params"`
+	Returns   []string       `json:"returns"`
+	Receiver  string         `json:"receiver,omitempty"`
+	Position  *GraphPosition `json:"position,omitempty"`
+}
+
+type GraphPosition struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// GraphEdge the relation from the type to the other type, the kind is EdgeEmbed or EdgeImplement
+type GraphEdge struct {
+	Kind string `json:"kind"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph get the graph of the scanned types, and the relations of all interfaces are found if they aren't disabled
+func (s *Scanner) Graph() *Graph {
+	g := &Graph{
+		Version:    GraphVersion,
+		Interfaces: []*GraphType{},
+		Structs:    []*GraphType{},
+		Edges:      []*GraphEdge{},
+	}
+	for _, interfaceInfo := range s.interfaces {
+		g.Interfaces = append(g.Interfaces, newGraphType(interfaceInfo.BaseInfo, interfaceInfo.methods))
+		lo.ForEach[*InterfaceInfo](interfaceInfo.innerInterface, func(item *InterfaceInfo, _ int) {
+			g.Edges = append(g.Edges, &GraphEdge{Kind: EdgeEmbed, From: interfaceInfo.name, To: item.name})
+		})
+		if !s.enableImplement {
+			continue
+		}
+		s.resolveImplements(interfaceInfo)
+		lo.ForEach[*StructInfo](interfaceInfo.structs, func(item *StructInfo, _ int) {
+			g.Edges = append(g.Edges, &GraphEdge{Kind: EdgeImplement, From: item.name, To: interfaceInfo.name})
+		})
+	}
+	for _, structInfo := range s.structs {
+		g.Structs = append(g.Structs, newGraphType(structInfo.BaseInfo, structInfo.Methods()))
+		lo.ForEach[*StructInfo](structInfo.innerStruct, func(item *StructInfo, _ int) {
+			g.Edges = append(g.Edges, &GraphEdge{Kind: EdgeEmbed, From: structInfo.name, To: item.name})
+		})
+		lo.ForEach[*InterfaceInfo](structInfo.innerInterface, func(item *InterfaceInfo, _ int) {
+			g.Edges = append(g.Edges, &GraphEdge{Kind: EdgeEmbed, From: structInfo.name, To: item.name})
+		})
+	}
+
+	sort.Slice(g.Interfaces, func(i, j int) bool {
+		return g.Interfaces[i].Name < g.Interfaces[j].Name
+	})
+	sort.Slice(g.Structs, func(i, j int) bool {
+		return g.Structs[i].Name < g.Structs[j].Name
+	})
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return g
+}
+
+func newGraphType(info *BaseInfo, methods []*MethodInfo) *GraphType {
+	return &GraphType{
+		Name:        info.name,
+		Package:     info.packageName,
+		PackageName: info.pkgName,
+		Position:    newGraphPosition(info.position),
+		Files:       lo.Uniq[string](info.filePaths),
+		ReadOnly:    info.readOnly,
+		Methods: lo.Map[*MethodInfo, *GraphMethod](methods, func(item *MethodInfo, _ int) *GraphMethod {
+			return &GraphMethod{
+				Name:      item.name,
+				Signature: item.Signature(),
+				Params:    lo.Ternary[[]string](item.params == nil, []string{}, item.params),
+				Returns:   lo.Ternary[[]string](item.returns == nil, []string{}, item.returns),
+				Receiver:  item.receiverType,
+				Position:  newGraphPosition(item.position),
+			}
+		}),
+	}
+}
+
+func newGraphPosition(position Position) *GraphPosition {
+	if position.File == "" {
+		return nil
+	}
+	return &GraphPosition{File: position.File, Line: position.Line}
+}
+
+// Signature the method signature without the parameter names, like: Foo(int, string) (int, error)
+func (m *MethodInfo) Signature() string {
+	signature := m.name + "(" + strings.Join(m.params, ", ") + ")"
+	switch len(m.returns) {
+	case 0:
+		return signature
+	case 1:
+		return signature + " " + m.returns[0]
+	default:
+		return signature + " (" + strings.Join(m.returns, ", ") + ")"
+	}
+}
+
+// TypesInPackages get the names of the types whose packages are matched
+func (g *Graph) TypesInPackages(match func(packagePath string) bool) []string {
+	var names []string
+	lo.ForEach[*GraphType](append(append([]*GraphType{}, g.Interfaces...), g.Structs...), func(item *GraphType, _ int) {
+		if match(item.Package) {
+			names = append(names, item.Name)
+		}
+	})
+	return names
+}
+
+// Filter get the sub graph of the types within the depth from the roots, the edges are walked in both directions.
+// The depth 0 only keeps the roots, and the negative depth means no limit.
+func (g *Graph) Filter(roots []string, depth int) *Graph {
+	neighbors := make(map[string][]string)
+	lo.ForEach[*GraphEdge](g.Edges, func(item *GraphEdge, _ int) {
+		neighbors[item.From] = append(neighbors[item.From], item.To)
+		neighbors[item.To] = append(neighbors[item.To], item.From)
+	})
+
+	kept := make(map[string]bool)
+	current := lo.Uniq[string](roots)
+	lo.ForEach[string](current, func(item string, _ int) {
+		kept[item] = true
+	})
+	for level := 0; len(current) > 0 && (depth < 0 || level < depth); level++ {
+		var next []string
+		lo.ForEach[string](current, func(name string, _ int) {
+			lo.ForEach[string](neighbors[name], func(item string, _ int) {
+				if !kept[item] {
+					kept[item] = true
+					next = append(next, item)
+				}
+			})
+		})
+		current = next
+	}
+
+	isKept := func(item *GraphType, _ int) bool {
+		return kept[item.Name]
+	}
+	return &Graph{
+		Version:    g.Version,
+		Interfaces: lo.Filter[*GraphType](g.Interfaces, isKept),
+		Structs:    lo.Filter[*GraphType](g.Structs, isKept),
+		Edges: lo.Filter[*GraphEdge](g.Edges, func(item *GraphEdge, _ int) bool {
+			return kept[item.From] && kept[item.To]
+		}),
+	}
+}
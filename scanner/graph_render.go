@@ -0,0 +1,118 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package scanner
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	"strings"
+)
+
+// labels get the short names of the types, like `pkg.Name` where the `pkg` is the declared package name,
+// and the full names are used if the short names are the same
+func (g *Graph) labels() map[string]string {
+	counts := make(map[string]int)
+	shortNames := make(map[string]string)
+	lo.ForEach[*GraphType](append(append([]*GraphType{}, g.Interfaces...), g.Structs...), func(item *GraphType, _ int) {
+		shortName := shortTypeName(item.Name)
+		if item.PackageName != "" {
+			shortName = item.PackageName + shortName[strings.LastIndex(shortName, "."):]
+		}
+		shortNames[item.Name] = shortName
+		counts[shortName]++
+	})
+	labels := make(map[string]string)
+	for name, shortName := range shortNames {
+		labels[name] = lo.Ternary[string](counts[shortName] > 1, name, shortName)
+	}
+	return labels
+}
+
+// nodeEdges get the edges whose endpoints are both the nodes of the graph,
+// the filtered graph or the hand-written document may keep the edge to the dropped type
+func (g *Graph) nodeEdges() []*GraphEdge {
+	nodes := make(map[string]struct{})
+	lo.ForEach[*GraphType](append(append([]*GraphType{}, g.Interfaces...), g.Structs...), func(item *GraphType, _ int) {
+		nodes[item.Name] = struct{}{}
+	})
+	return lo.Filter[*GraphEdge](g.Edges, func(item *GraphEdge, _ int) bool {
+		_, from := nodes[item.From]
+		_, to := nodes[item.To]
+		return from && to
+	})
+}
+
+// DOT render the graph in the Graphviz DOT language.
+// The interface is the ellipse, the struct is the box, the implements edge is dashed with the empty arrow, and the embedding edge is solid with the diamond.
+func (g *Graph) DOT() string {
+	labels := g.labels()
+	quote := func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph interfacer {\n")
+	b.WriteString("  rankdir=BT;\n")
+	b.WriteString("  node [fontname=\"Helvetica\"];\n")
+	lo.ForEach[*GraphType](g.Interfaces, func(item *GraphType, _ int) {
+		fmt.Fprintf(&b, "  %s [label=%s, tooltip=%s, shape=ellipse];\n", quote(item.Name), quote(labels[item.Name]), quote(item.Name))
+	})
+	lo.ForEach[*GraphType](g.Structs, func(item *GraphType, _ int) {
+		fmt.Fprintf(&b, "  %s [label=%s, tooltip=%s, shape=box];\n", quote(item.Name), quote(labels[item.Name]), quote(item.Name))
+	})
+	lo.ForEach[*GraphEdge](g.nodeEdges(), func(item *GraphEdge, _ int) {
+		style := "style=solid, arrowhead=diamond"
+		if item.Kind == EdgeImplement {
+			style = "style=dashed, arrowhead=empty"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", quote(item.From), quote(item.To), style)
+	})
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid render the graph as the Mermaid flowchart.
+// The interface is the stadium node, the struct is the rectangle node, the implements edge is dotted, and the embedding edge is thick.
+func (g *Graph) Mermaid() string {
+	labels := g.labels()
+	// the full names can't be the node ids of the mermaid
+	ids := make(map[string]string)
+	node := func(item *GraphType, format string) string {
+		ids[item.Name] = fmt.Sprintf("n%d", len(ids))
+		label := strings.ReplaceAll(labels[item.Name], `"`, "#quot;")
+		return fmt.Sprintf(format, ids[item.Name], label)
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart BT\n")
+	lo.ForEach[*GraphType](g.Interfaces, func(item *GraphType, _ int) {
+		b.WriteString("  " + node(item, `%s(["%s"]):::interface`) + "\n")
+	})
+	lo.ForEach[*GraphType](g.Structs, func(item *GraphType, _ int) {
+		b.WriteString("  " + node(item, `%s["%s"]:::struct`) + "\n")
+	})
+	lo.ForEach[*GraphEdge](g.nodeEdges(), func(item *GraphEdge, _ int) {
+		arrow := "==>|embeds|"
+		if item.Kind == EdgeImplement {
+			arrow = "-.->|implements|"
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", ids[item.From], arrow, ids[item.To])
+	})
+	b.WriteString("  classDef interface fill:#e3f2fd,stroke:#1565c0\n")
+	b.WriteString("  classDef struct fill:#fff8e1,stroke:#ef6c00\n")
+	return b.String()
+}
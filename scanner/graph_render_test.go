@@ -0,0 +1,68 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGraphRenderSkipMissingEndpoints(t *testing.T) {
+	g := &Graph{
+		Interfaces: []*GraphType{{Name: "github.com/foo/i.Component", Package: "github.com/foo/i"}},
+		Structs:    []*GraphType{{Name: "github.com/foo/s.Node", Package: "github.com/foo/s"}},
+		Edges: []*GraphEdge{
+			{Kind: EdgeImplement, From: "github.com/foo/s.Node", To: "github.com/foo/i.Component"},
+			{Kind: EdgeEmbed, From: "github.com/foo/s.Node", To: "github.com/foo/s.Dropped"},
+			{Kind: EdgeImplement, From: "github.com/foo/s.Dropped", To: "github.com/foo/i.Component"},
+		},
+	}
+	tests := []struct {
+		name   string
+		render func() string
+		want   []string
+		unwant []string
+	}{
+		{
+			name:   "dot",
+			render: g.DOT,
+			want:   []string{`"github.com/foo/s.Node" -> "github.com/foo/i.Component" [style=dashed, arrowhead=empty];`},
+			unwant: []string{"s.Dropped"},
+		},
+		{
+			name:   "mermaid",
+			render: g.Mermaid,
+			want:   []string{"  n1 -.->|implements| n0\n"},
+			unwant: []string{"  n1 ==>|embeds| \n", "  -.->|implements| n0\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := tt.render()
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("want %q in the output:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.unwant {
+				if strings.Contains(out, s) {
+					t.Errorf("unwant %q in the output:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
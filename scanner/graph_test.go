@@ -0,0 +1,140 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package scanner
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGraph(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"i/i.go": "package i\n\ntype Closer interface {\n\tClose() error\n}\n\n" +
+			"type ReadCloser interface {\n\tCloser\n\tRead(n int) (int, error)\n}\n",
+		"a/a.go": "package a\n\ntype Foo struct{}\n\nfunc (f *Foo) Close() error {\n\treturn nil\n}\n\n" +
+			"type Bar struct {\n\tFoo\n}\n\nfunc (b Bar) Read(n int) (int, error) {\n\treturn 0, nil\n}\n",
+	})
+	s := New("github.com/foo", dir)
+	s.DisableProgress()
+	if err := s.Start(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := json.Marshal(s.Graph())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Graph{}
+	if err = json.Unmarshal(content, g); err != nil {
+		t.Fatal(err)
+	}
+	if g.Version != GraphVersion {
+		t.Errorf("the version is %d, want %d", g.Version, GraphVersion)
+	}
+
+	names := func(types []*GraphType) []string {
+		var result []string
+		for _, item := range types {
+			result = append(result, item.Name)
+		}
+		return result
+	}
+	if got, want := names(g.Interfaces), []string{"github.com/foo/i.Closer", "github.com/foo/i.ReadCloser"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("the interfaces are %v, want %v", got, want)
+	}
+	if got, want := names(g.Structs), []string{"github.com/foo/a.Bar", "github.com/foo/a.Foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("the structs are %v, want %v", got, want)
+	}
+
+	wantEdges := []*GraphEdge{
+		{Kind: EdgeEmbed, From: "github.com/foo/a.Bar", To: "github.com/foo/a.Foo"},
+		{Kind: EdgeEmbed, From: "github.com/foo/i.ReadCloser", To: "github.com/foo/i.Closer"},
+		{Kind: EdgeImplement, From: "github.com/foo/a.Bar", To: "github.com/foo/i.Closer"},
+		{Kind: EdgeImplement, From: "github.com/foo/a.Bar", To: "github.com/foo/i.ReadCloser"},
+		{Kind: EdgeImplement, From: "github.com/foo/a.Foo", To: "github.com/foo/i.Closer"},
+	}
+	if !reflect.DeepEqual(g.Edges, wantEdges) {
+		t.Errorf("the edges are %s, want %s", toJSON(t, g.Edges), toJSON(t, wantEdges))
+	}
+
+	// only the declared methods are exported, the embedded ones are in the edges
+	wantBar := &GraphType{
+		Name:        "github.com/foo/a.Bar",
+		Package:     "github.com/foo/a",
+		PackageName: "a",
+		Position:    &GraphPosition{File: filepath.Join(dir, "a", "a.go"), Line: 9},
+		Files:       []string{filepath.Join(dir, "a", "a.go")},
+		Methods: []*GraphMethod{{
+			Name:      "Read",
+			Signature: "Read(int) (int, error)",
+			Params:    []string{"int"},
+			Returns:   []string{"int", "error"},
+			Receiver:  "Bar",
+			Position:  &GraphPosition{File: filepath.Join(dir, "a", "a.go"), Line: 13},
+		}},
+	}
+	if !reflect.DeepEqual(g.Structs[0], wantBar) {
+		t.Errorf("the struct is %s, want %s", toJSON(t, g.Structs[0]), toJSON(t, wantBar))
+	}
+}
+
+func TestGraphWithoutImplements(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a/a.go": "package a\n\ntype Closer interface {\n\tClose() error\n}\n\ntype Foo struct{}\n\nfunc (f Foo) Close() error {\n\treturn nil\n}\n",
+	})
+	s := New("github.com/foo", dir)
+	s.DisableProgress()
+	s.DisableImplementRelation()
+	if err := s.Start(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	g := s.Graph()
+	if len(g.Interfaces) != 1 || len(g.Structs) != 1 {
+		t.Fatalf("the graph has %d interfaces and %d structs, want 1 and 1", len(g.Interfaces), len(g.Structs))
+	}
+	if len(g.Edges) != 0 {
+		t.Errorf("the edges are %s, want none", toJSON(t, g.Edges))
+	}
+}
+
+func TestSignature(t *testing.T) {
+	tests := []struct {
+		method *MethodInfo
+		want   string
+	}{
+		{method: &MethodInfo{name: "Foo"}, want: "Foo()"},
+		{method: &MethodInfo{name: "Foo", params: []string{"int", "string"}, returns: []string{"error"}}, want: "Foo(int, string) error"},
+		{method: &MethodInfo{name: "Foo", params: []string{"...int"}, returns: []string{"int", "error"}}, want: "Foo(...int) (int, error)"},
+	}
+	for _, tt := range tests {
+		if got := tt.method.Signature(); got != tt.want {
+			t.Errorf("Signature() = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func toJSON(t *testing.T, v any) string {
+	content, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
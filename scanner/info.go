@@ -26,11 +26,21 @@ import (
 	"strings"
 )
 
+// Position the position of the declaration in the file
+type Position struct {
+	File string
+	Line int
+}
+
 type BaseInfo struct {
 	filePaths   []string
 	packageName string
-	name        string
-	tokens      []string
+	// pkgName the name declared by the package clause
+	pkgName  string
+	name     string
+	tokens   []string
+	readOnly bool
+	position Position
 }
 
 func (b *BaseInfo) FilePaths() []string {
@@ -41,17 +51,101 @@ func (b *BaseInfo) Name() string {
 	return b.name
 }
 
+// Position the position of the type declaration, it's empty if the declaration isn't scanned
+func (b *BaseInfo) Position() Position {
+	return b.position
+}
+
+// PackagePath the import path of the package that the type belongs to
+func (b *BaseInfo) PackagePath() string {
+	return b.packageName
+}
+
+// PackageName the name declared by the package clause, which maybe isn't the last element of the package path
+func (b *BaseInfo) PackageName() string {
+	return b.pkgName
+}
+
+// ShortName the qualified name used by the code in the other packages, like `iface.Foo` for the package `iface` whose path is `xxx/i`
+func (b *BaseInfo) ShortName() string {
+	return b.pkgName + "." + b.name[strings.LastIndex(b.name, ".")+1:]
+}
+
+// IsReadOnly whether the type is in the read-only module, like the `vendor` dir, so it can't be written
+func (b *BaseInfo) IsReadOnly() bool {
+	return b.readOnly
+}
+
+// IsTestOnly whether all files of the type are the `_test.go` files, like the hand-written fakes
+func (b *BaseInfo) IsTestOnly() bool {
+	if len(b.filePaths) == 0 {
+		return false
+	}
+	return lo.EveryBy[string](b.filePaths, tool.IsTestFile)
+}
+
 type StructInfo struct {
 	*BaseInfo
 	// map is easy to check whether it has implemented the interface
 	methods        map[string]*MethodInfo
 	innerStruct    []*StructInfo
 	innerInterface []*InterfaceInfo
+	// pointerEmbeds the full names of the structs embedded by the pointer
+	pointerEmbeds map[string]bool
+	// valueTokens the tokens of the methods which can be called by the value, the tokens are the methods of the pointer
+	valueTokens []string
+}
+
+// Methods the methods declared by the struct, which are sorted by the names
+func (s *StructInfo) Methods() []*MethodInfo {
+	methods := lo.Values[string, *MethodInfo](s.methods)
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].name < methods[j].name
+	})
+	return methods
+}
+
+// InnerStructs the embedded structs
+func (s *StructInfo) InnerStructs() []*StructInfo {
+	return s.innerStruct
+}
+
+// InnerInterfaces the embedded interfaces
+func (s *StructInfo) InnerInterfaces() []*InterfaceInfo {
+	return s.innerInterface
+}
+
+// IsPointerEmbed whether the struct is embedded by the pointer, like: struct { *Foo }
+func (s *StructInfo) IsPointerEmbed(inner *StructInfo) bool {
+	return s.pointerEmbeds[inner.name]
 }
 
 func (s *StructInfo) Tokens() {
 	s.tokens = s.innerToken()
 	sort.Strings(s.tokens)
+	s.valueTokens = s.innerValueToken()
+	sort.Strings(s.valueTokens)
+}
+
+// innerValueToken the method set of the value, including the value receiver methods and the promoted methods
+func (s *StructInfo) innerValueToken() []string {
+	var tokens []string
+	for _, info := range s.methods {
+		if !info.isPointReceiver {
+			tokens = append(tokens, info.token())
+		}
+	}
+	lo.ForEach[*InterfaceInfo](s.innerInterface, func(item *InterfaceInfo, index int) {
+		tokens = append(tokens, item.innerToken()...)
+	})
+	lo.ForEach[*StructInfo](s.innerStruct, func(item *StructInfo, index int) {
+		if s.pointerEmbeds[item.name] {
+			tokens = append(tokens, item.innerToken()...)
+		} else {
+			tokens = append(tokens, item.innerValueToken()...)
+		}
+	})
+	return tokens
 }
 
 func (s *StructInfo) innerToken() []string {
@@ -69,13 +163,22 @@ func (s *StructInfo) innerToken() []string {
 }
 
 func (s *StructInfo) HasImplementInterface(i *InterfaceInfo) bool {
+	return hasImplementTokens(s.tokens, i)
+}
+
+// HasImplementInterfaceByValue whether the value of the struct implements the interface, otherwise maybe only the pointer implements it
+func (s *StructInfo) HasImplementInterfaceByValue(i *InterfaceInfo) bool {
+	return hasImplementTokens(s.valueTokens, i)
+}
+
+func hasImplementTokens(tokens []string, i *InterfaceInfo) bool {
 	var x, y int
 
-	for x < len(s.tokens) && y < len(i.tokens) {
-		if s.tokens[x] == i.tokens[y] {
+	for x < len(tokens) && y < len(i.tokens) {
+		if tokens[x] == i.tokens[y] {
 			x++
 			y++
-		} else if s.tokens[x] < i.tokens[y] {
+		} else if tokens[x] < i.tokens[y] {
 			x++
 		} else if slices.Contains(i.excludeTokens, i.tokens[y]) {
 			y++
@@ -87,13 +190,54 @@ func (s *StructInfo) HasImplementInterface(i *InterfaceInfo) bool {
 	return y == len(i.tokens)
 }
 
-func (s *StructInfo) MethodReceiver() (receiverName string, receiverType string) {
+// MethodReceiver the receiver used by the most methods of the struct, like `f *Foo`.
+// It returns an AmbiguousError if the different receiver names are used by the same number of methods.
+func (s *StructInfo) MethodReceiver() (receiverName string, receiverType string, err error) {
+	names := make(map[string]int)
+	// receiver name -> receiver type -> count
+	types := make(map[string]map[string]int)
 	for _, info := range s.methods {
-		if info.receiverName != "" {
-			return info.receiverName, info.receiverType
+		if info.receiverName == "" || info.receiverName == "_" {
+			continue
+		}
+		names[info.receiverName]++
+		if types[info.receiverName] == nil {
+			types[info.receiverName] = make(map[string]int)
+		}
+		types[info.receiverName][info.receiverType]++
+	}
+	if len(names) == 0 {
+		typeName := s.name[strings.LastIndex(s.name, ".")+1:]
+		return strings.ToLower(typeName[:1]), "*" + typeName, nil
+	}
+
+	receiverName, candidates := mostCommon(names)
+	if len(candidates) > 1 {
+		return "", "", &tool.AmbiguousError{Kind: "receiver", Name: s.name, Candidates: candidates}
+	}
+	receiverType, candidates = mostCommon(types[receiverName])
+	// prefer the pointer receiver, which can be used for all methods
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, "*") {
+			receiverType = candidate
+		}
+	}
+	return receiverName, receiverType, nil
+}
+
+// mostCommon get the key with the max count, and all keys with the max count, which are sorted
+func mostCommon(counts map[string]int) (string, []string) {
+	var candidates []string
+	max := 0
+	for key, count := range counts {
+		if count > max {
+			max, candidates = count, []string{key}
+		} else if count == max {
+			candidates = append(candidates, key)
 		}
 	}
-	return strings.ToLower(s.name), "*" + s.name
+	sort.Strings(candidates)
+	return candidates[0], candidates
 }
 
 func (s *StructInfo) Print() {
@@ -120,12 +264,23 @@ type InterfaceInfo struct {
 	methods        []*MethodInfo
 	structs        []*StructInfo // implement the interface
 	excludeTokens  []string
+	resolved       bool // whether the structs have been found
 }
 
 func (i *InterfaceInfo) GetImplements() []*StructInfo {
 	return i.structs
 }
 
+// Methods the methods declared by the interface in order, the methods of the embedded interfaces aren't included
+func (i *InterfaceInfo) Methods() []*MethodInfo {
+	return i.methods
+}
+
+// InnerInterfaces the embedded interfaces
+func (i *InterfaceInfo) InnerInterfaces() []*InterfaceInfo {
+	return i.innerInterface
+}
+
 func (i *InterfaceInfo) Tokens() {
 	i.tokens = i.innerToken()
 	sort.Strings(i.tokens)
@@ -144,7 +299,7 @@ func (i *InterfaceInfo) innerToken() []string {
 
 func (i *InterfaceInfo) ExcludeTokens(methods []string) {
 	lo.ForEach[string](methods, func(item string, index int) {
-		if token := i.innerExcludeToken(item); token != "" {
+		if token := i.innerExcludeToken(item); token != "" && !slices.Contains(i.excludeTokens, token) {
 			i.excludeTokens = append(i.excludeTokens, token)
 		}
 	})
@@ -191,6 +346,34 @@ type MethodInfo struct {
 	types           []string
 	params          []string
 	returns         []string
+	position        Position
+}
+
+func (m *MethodInfo) Name() string {
+	return m.name
+}
+
+// Params the full names of the param types
+func (m *MethodInfo) Params() []string {
+	return m.params
+}
+
+// Returns the full names of the return types
+func (m *MethodInfo) Returns() []string {
+	return m.returns
+}
+
+// Receiver the receiver name and type of the struct method, they are empty for the interface method
+func (m *MethodInfo) Receiver() (name string, typ string) {
+	return m.receiverName, m.receiverType
+}
+
+func (m *MethodInfo) IsPointReceiver() bool {
+	return m.isPointReceiver
+}
+
+func (m *MethodInfo) Position() Position {
+	return m.position
 }
 
 func (m *MethodInfo) token() string {
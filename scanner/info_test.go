@@ -0,0 +1,92 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package scanner
+
+import (
+	"github.com/SimFG/interfacer/tool"
+	"testing"
+)
+
+func TestMethodReceiver(t *testing.T) {
+	method := func(receiverName string, receiverType string) *MethodInfo {
+		return &MethodInfo{receiverName: receiverName, receiverType: receiverType}
+	}
+	tests := []struct {
+		name      string
+		methods   []*MethodInfo
+		wantName  string
+		wantType  string
+		ambiguous bool
+	}{
+		{
+			name:     "no method uses the short type name",
+			wantName: "f",
+			wantType: "*Foo",
+		},
+		{
+			name:     "unnamed receivers use the short type name",
+			methods:  []*MethodInfo{method("", "Foo"), method("_", "*Foo")},
+			wantName: "f",
+			wantType: "*Foo",
+		},
+		{
+			name:     "most common name",
+			methods:  []*MethodInfo{method("foo", "*Foo"), method("foo", "*Foo"), method("f", "*Foo")},
+			wantName: "foo",
+			wantType: "*Foo",
+		},
+		{
+			name:     "pointer is preferred",
+			methods:  []*MethodInfo{method("f", "Foo"), method("f", "*Foo")},
+			wantName: "f",
+			wantType: "*Foo",
+		},
+		{
+			name:     "most common type",
+			methods:  []*MethodInfo{method("f", "Foo"), method("f", "Foo"), method("f", "*Foo")},
+			wantName: "f",
+			wantType: "Foo",
+		},
+		{
+			name:      "ambiguous name",
+			methods:   []*MethodInfo{method("f", "*Foo"), method("foo", "*Foo")},
+			ambiguous: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &StructInfo{BaseInfo: &BaseInfo{name: "github.com/foo/bar.Foo"}, methods: make(map[string]*MethodInfo)}
+			for i, info := range tt.methods {
+				info.name = string(rune('A' + i))
+				s.methods[info.name] = info
+			}
+			name, typ, err := s.MethodReceiver()
+			if tt.ambiguous {
+				if _, ok := err.(*tool.AmbiguousError); !ok {
+					t.Fatalf("want the AmbiguousError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name != tt.wantName || typ != tt.wantType {
+				t.Errorf("MethodReceiver() = %s %s, want %s %s", name, typ, tt.wantName, tt.wantType)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package scanner
+
+import (
+	"github.com/SimFG/interfacer/tool"
+	"go.uber.org/zap"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// setPackageName record the name declared by the package clause of the scanned package
+func (s *Scanner) setPackageName(importPath string, name string) {
+	s.namesMu.Lock()
+	defer s.namesMu.Unlock()
+	s.packageNames[importPath] = name
+}
+
+// PackageName get the name declared by the package clause of the imported package, which maybe isn't the last element of the import path.
+// The package clause is read from the dir of the package in the scanned modules, and the name is guessed by the import path if the dir isn't found.
+func (s *Scanner) PackageName(importPath string) string {
+	s.namesMu.Lock()
+	name, ok := s.packageNames[importPath]
+	s.namesMu.Unlock()
+	if ok {
+		return name
+	}
+
+	// read the dir without the lock, so the parallel workers don't wait for each other
+	if dir := s.packageDir(importPath); dir != "" {
+		name = readPackageClause(dir)
+	}
+	if name == "" {
+		name = tool.ImportName(importPath)
+	}
+
+	s.namesMu.Lock()
+	defer s.namesMu.Unlock()
+	// the other worker or the scanned package may have set the name during the reading
+	if cached, ok := s.packageNames[importPath]; ok {
+		return cached
+	}
+	tool.Info("resolve the package name", zap.String("import_path", importPath), zap.String("name", name))
+	s.packageNames[importPath] = name
+	return name
+}
+
+// packageDir get the dir of the import path in the scanned modules, the module with the longest path is used
+func (s *Scanner) packageDir(importPath string) string {
+	dir, modulePath := "", ""
+	for _, m := range s.modules {
+		if m.ReadOnly && m.Path == "" {
+			// the import path of the dir in the `vendor` dir is the relative path
+			if dir == "" {
+				dir = tool.PathJoin(m.Dir, filepath.FromSlash(importPath))
+			}
+			continue
+		}
+		if importPath != m.Path && !strings.HasPrefix(importPath, m.Path+"/") {
+			continue
+		}
+		if modulePath == "" || len(m.Path) > len(modulePath) {
+			modulePath = m.Path
+			dir = tool.PathJoin(m.Dir, filepath.FromSlash(strings.TrimPrefix(importPath[len(m.Path):], "/")))
+		}
+	}
+	if dir == "" {
+		return ""
+	}
+	return filepath.Clean(dir)
+}
+
+// readPackageClause get the package name of the go files in the dir, the test files and the `main` package are ignored
+func readPackageClause(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || tool.IsTestFile(entry.Name()) {
+			continue
+		}
+		astFile, err := parser.ParseFile(token.NewFileSet(), tool.PathJoin(dir, entry.Name()), nil, parser.PackageClauseOnly)
+		if err != nil || astFile.Name.Name == "main" {
+			continue
+		}
+		return astFile.Name.Name
+	}
+	return ""
+}
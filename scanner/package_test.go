@@ -0,0 +1,81 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestPackageName(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("v2/a.go", "package foo\n")
+	writeFile("cmd/main.go", "package main\n")
+	writeFile("cmd/b.go", "package bar\n")
+	writeFile("only_test/a_test.go", "package baz\n")
+
+	tests := []struct {
+		name       string
+		importPath string
+		want       string
+	}{
+		{"package clause", "github.com/foo/v2", "foo"},
+		{"main is ignored", "github.com/foo/cmd", "bar"},
+		{"test files are ignored", "github.com/foo/only_test", "only_test"},
+		{"not in the module", "github.com/other/go-yaml", "yaml"},
+		{"missing dir", "github.com/foo/missing", "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New("github.com/foo", dir)
+			var wg sync.WaitGroup
+			names := make([]string, 8)
+			for i := range names {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					names[i] = s.PackageName(tt.importPath)
+				}(i)
+			}
+			wg.Wait()
+			for _, name := range names {
+				if name != tt.want {
+					t.Errorf("PackageName(%q) = %q, want %q", tt.importPath, name, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPackageNameScanned(t *testing.T) {
+	s := New("github.com/foo", t.TempDir())
+	s.setPackageName("github.com/foo/v2", "foo")
+	if name := s.PackageName("github.com/foo/v2"); name != "foo" {
+		t.Errorf("PackageName = %q, want the scanned name foo", name)
+	}
+}
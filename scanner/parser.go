@@ -21,41 +21,58 @@ import (
 	"github.com/samber/lo"
 	"go.uber.org/zap"
 	"go/ast"
+	"go/token"
 	"strings"
+	"sync/atomic"
 )
 
 type PackageParser struct {
-	scanner            *Scanner
-	curPack            string
-	curDir             string
-	astPack            *ast.Package
+	scanner *Scanner
+	curPack string
+	curDir  string
+	// pkgName the name declared by the package clause, which maybe isn't the last element of the curPack
+	pkgName            string
+	readOnly           bool
+	results            []*FileResult
 	structs            []string
 	interfaces         []string
 	innerStructPost    map[string][]string
 	innerInterfacePost map[string][]string
 }
 
-func NewPackageParser(s *Scanner, curPack string, curDir string, astPack *ast.Package) *PackageParser {
+func NewPackageParser(s *Scanner, curPack string, curDir string, results []*FileResult) *PackageParser {
 	tool.Info("NewPackageParser", zap.String("cur_pack", curPack), zap.String("cur_dir", curDir))
 	return &PackageParser{
 		scanner:            s,
 		curPack:            curPack,
 		curDir:             curDir,
-		astPack:            astPack,
+		readOnly:           s.isReadOnly(curDir),
+		results:            results,
+		pkgName:            packageNameOf(results),
 		innerStructPost:    make(map[string][]string),
 		innerInterfacePost: make(map[string][]string),
 	}
 }
 
+func packageNameOf(results []*FileResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+	return results[0].PackageName
+}
+
 func (p *PackageParser) Parse() {
-	for name, file := range p.astPack.Files {
-		p.ParseFile(name, file)
-		p.scanner.currentNum++
+	for _, result := range p.results {
+		p.ApplyFile(result)
+		atomic.AddInt64(&p.scanner.currentNum, 1)
 	}
 
+	p.scanner.mu.Lock()
+	defer p.scanner.mu.Unlock()
 	p.handInner()
 }
 
+// handInner the caller should hold the lock of the scanner
 func (p *PackageParser) handInner() {
 	tool.Info("handInner innerStructPost")
 	for s, i := range p.innerStructPost {
@@ -115,6 +132,10 @@ func (p *PackageParser) handInner() {
 }
 
 func (p *PackageParser) HandleFieldListForInterface(fields *ast.FieldList, f func(value string, namesLen int)) {
+	handleFieldList(fields, f)
+}
+
+func handleFieldList(fields *ast.FieldList, f func(value string, namesLen int)) {
 	if fields == nil {
 		return
 	}
@@ -130,14 +151,18 @@ func (p *PackageParser) HandleFieldListForInterface(fields *ast.FieldList, f fun
 }
 
 func (p *PackageParser) HandleFuncType(funcType *ast.FuncType, methodInfo *MethodInfo) {
-	p.HandleFieldListForInterface(funcType.Params, func(value string, namesLen int) {
+	handleFuncType(funcType, methodInfo)
+}
+
+func handleFuncType(funcType *ast.FuncType, methodInfo *MethodInfo) {
+	handleFieldList(funcType.Params, func(value string, namesLen int) {
 		tool.IfF(value != "", func() {
 			tool.Times(namesLen, func(index int) {
 				methodInfo.params = append(methodInfo.params, value)
 			})
 		})
 	})
-	p.HandleFieldListForInterface(funcType.Results, func(value string, namesLen int) {
+	handleFieldList(funcType.Results, func(value string, namesLen int) {
 		tool.IfF(value != "", func() {
 			tool.Times(namesLen, func(index int) {
 				methodInfo.returns = append(methodInfo.returns, value)
@@ -146,63 +171,65 @@ func (p *PackageParser) HandleFuncType(funcType *ast.FuncType, methodInfo *Metho
 	})
 }
 
-func (p *PackageParser) ParseFile(fileFullPath string, astFile *ast.File) {
-	tool.Info("PackageParser ParseFile", zap.String("file_full_path", fileFullPath), zap.Any("ast_file_name", astFile.Name))
+func (p *PackageParser) ParseFile(fset *token.FileSet, fileFullPath string, astFile *ast.File) {
+	p.ApplyFile(NewFileResult(fset, fileFullPath, astFile))
+}
 
-	var (
-		importList      = make(map[string]string)
-		structList      = make(map[string]*StructInfo)
-		interfaceList   = make(map[string]*InterfaceInfo)
-		funcList        = make(map[string][]*MethodInfo) // the method maybe use the struct which isn't scanned
-		innerInterfaces = make(map[string][]string)
-		innerStructs    = make(map[string][]string)
-	)
+// NewFileResult get the syntax info of the file, the fset is used to get the lines of the declarations
+func NewFileResult(fset *token.FileSet, fileFullPath string, astFile *ast.File) *FileResult {
+	tool.Info("NewFileResult", zap.String("file_full_path", fileFullPath), zap.Any("ast_file_name", astFile.Name))
 
+	result := newFileResult(fileFullPath, astFile.Name.Name)
+	line := func(pos token.Pos) int {
+		if fset == nil {
+			return 0
+		}
+		return fset.Position(pos).Line
+	}
 	ast.Inspect(astFile, func(x ast.Node) bool {
 		switch x.(type) {
 		case *ast.ImportSpec:
 			importSpec := x.(*ast.ImportSpec)
 			v := strings.Trim(importSpec.Path.Value, "\"")
 			if importSpec.Name != nil {
-				importList[importSpec.Name.Name] = v
+				result.Imports[importSpec.Name.Name] = v
 			} else {
-				importList[tool.ImportName(v)] = v
+				result.PlainImports = append(result.PlainImports, v)
 			}
 		case *ast.TypeSpec:
 			typeSpec := x.(*ast.TypeSpec)
 			typeName := typeSpec.Name.Name
-			baseInfo := &BaseInfo{name: p.curPack + "." + typeName, packageName: p.curPack, filePaths: []string{fileFullPath}}
+			result.Lines[typeName] = line(typeSpec.Name.Pos())
 			switch typeSpec.Type.(type) {
 			case *ast.StructType:
 				structType := typeSpec.Type.(*ast.StructType)
 				for _, field := range structType.Fields.List {
 					if len(field.Names) == 0 {
 						value := tool.GetValueFromType(field.Type)
-						innerStructs[typeName] = append(innerStructs[typeName], value)
+						result.InnerStructs[typeName] = append(result.InnerStructs[typeName], value)
 					}
 				}
-				structList[typeName] = &StructInfo{BaseInfo: baseInfo, methods: make(map[string]*MethodInfo)}
+				result.Structs = append(result.Structs, typeName)
 			case *ast.InterfaceType:
-				interfaceList[typeName] = &InterfaceInfo{BaseInfo: baseInfo}
+				result.Interfaces[typeName] = []*MethodRecord{}
 				interfaceType := typeSpec.Type.(*ast.InterfaceType)
 				if interfaceType.Methods != nil {
-					info := interfaceList[typeName]
 					for _, filed := range interfaceType.Methods.List {
 						switch filed.Type.(type) {
 						case *ast.FuncType: // TODO support generics type
 							funcName := filed.Names[0].Name
 							funcType := filed.Type.(*ast.FuncType)
-							methodInfo := &MethodInfo{name: funcName}
-							p.HandleFuncType(funcType, methodInfo)
+							methodInfo := &MethodInfo{name: funcName, position: Position{Line: line(filed.Names[0].Pos())}}
+							handleFuncType(funcType, methodInfo)
 
-							info.methods = append(info.methods, methodInfo)
+							result.Interfaces[typeName] = append(result.Interfaces[typeName], newMethodRecord(methodInfo))
 						default:
 							// get inner interface
 							value := tool.GetValueFromType(filed.Type)
 							if value == "" {
 								tool.Info("default field type spec type", zap.String("type", tool.TypeString(filed.Type)))
 							} else {
-								innerInterfaces[typeName] = append(innerInterfaces[typeName], value)
+								result.InnerInterfaces[typeName] = append(result.InnerInterfaces[typeName], value)
 							}
 						}
 
@@ -215,7 +242,7 @@ func (p *PackageParser) ParseFile(fileFullPath string, astFile *ast.File) {
 			funcDecl := x.(*ast.FuncDecl)
 			tool.IfF(funcDecl.Recv != nil, func() {
 				funcName := funcDecl.Name.Name
-				methodInfo := &MethodInfo{name: funcName}
+				methodInfo := &MethodInfo{name: funcName, position: Position{Line: line(funcDecl.Name.Pos())}}
 				structName := tool.GetValueFromType(funcDecl.Recv.List[0].Type)
 				if len(structName) == 0 {
 					//tool.PrintDetail("FuncDecl-receiver", funcDecl.Recv.List[0].Type)
@@ -232,9 +259,9 @@ func (p *PackageParser) ParseFile(fileFullPath string, astFile *ast.File) {
 						methodInfo.isPointReceiver = true
 						structName = structName[1:]
 					})
-					p.HandleFuncType(funcDecl.Type, methodInfo)
+					handleFuncType(funcDecl.Type, methodInfo)
 					//log.Println("funcDecl", fmt.Sprintf("%#v", methodInfo))
-					funcList[structName] = append(funcList[structName], methodInfo)
+					result.Funcs[structName] = append(result.Funcs[structName], newMethodRecord(methodInfo))
 				}
 			})
 		default:
@@ -244,6 +271,42 @@ func (p *PackageParser) ParseFile(fileFullPath string, astFile *ast.File) {
 		}
 		return true
 	})
+	return result
+}
+
+// ApplyFile convert the type names of the file to the full names, and add the types to the scanner
+func (p *PackageParser) ApplyFile(result *FileResult) {
+	tool.Info("PackageParser ApplyFile", zap.String("file_full_path", result.Path))
+
+	var (
+		fileFullPath    = result.Path
+		importList      = make(map[string]string)
+		structList      = make(map[string]*StructInfo)
+		interfaceList   = make(map[string]*InterfaceInfo)
+		funcList        = make(map[string][]*MethodInfo) // the method maybe use the struct which isn't scanned
+		innerInterfaces = result.InnerInterfaces
+		innerStructs    = result.InnerStructs
+	)
+	for alias, importPath := range result.Imports {
+		importList[alias] = importPath
+	}
+	// the package is referred by the declared name, like `iface.Foo` for the import path `xxx/i`
+	for _, importPath := range result.PlainImports {
+		importList[p.scanner.PackageName(importPath)] = importPath
+	}
+	newBaseInfo := func(typeName string) *BaseInfo {
+		return &BaseInfo{name: p.curPack + "." + typeName, packageName: p.curPack, pkgName: p.pkgName, filePaths: []string{fileFullPath}, readOnly: p.readOnly,
+			position: Position{File: fileFullPath, Line: result.Lines[typeName]}}
+	}
+	lo.ForEach[string](result.Structs, func(item string, _ int) {
+		structList[item] = &StructInfo{BaseInfo: newBaseInfo(item), methods: make(map[string]*MethodInfo), pointerEmbeds: make(map[string]bool)}
+	})
+	for name, methods := range result.Interfaces {
+		interfaceList[name] = &InterfaceInfo{BaseInfo: newBaseInfo(name), methods: methodInfos(fileFullPath, methods)}
+	}
+	for name, methods := range result.Funcs {
+		funcList[name] = methodInfos(fileFullPath, methods)
+	}
 
 	tool.Info("import list", zap.Any("value", importList))
 	tool.Info("struct list", zap.Any("value", structList))
@@ -288,6 +351,10 @@ func (p *PackageParser) ParseFile(fileFullPath string, astFile *ast.File) {
 		})
 	}
 
+	// the other packages maybe are parsed concurrently
+	p.scanner.mu.Lock()
+	defer p.scanner.mu.Unlock()
+
 	for _, info := range interfaceList {
 		lo.ForEach[*MethodInfo](info.methods, func(item *MethodInfo, index int) {
 			handMethod(item)
@@ -314,6 +381,10 @@ func (p *PackageParser) ParseFile(fileFullPath string, astFile *ast.File) {
 		fullStructName := p.curPack + "." + i
 		lo.ForEach[string](inners, func(item string, _ int) {
 			v, ok := handleInnerName(item)
+			if structInfo := structList[i]; structInfo != nil && item[0] == '*' {
+				// the methods of the pointer receiver are promoted to the value, like: struct { *Foo }
+				structInfo.pointerEmbeds[lo.If[string](ok, v).Else(p.curPack+"."+v)] = true
+			}
 			if ok {
 				p.scanner.postParserFuncs = append(p.scanner.postParserFuncs, &WrapperFunc{
 					CurrentName: fullStructName,
@@ -362,7 +433,11 @@ func (p *PackageParser) ParseFile(fileFullPath string, astFile *ast.File) {
 		if _, ok := p.scanner.structs[info.name]; ok {
 			curStructInfo := p.scanner.structs[info.name]
 			curStructInfo.packageName = info.packageName
+			curStructInfo.pkgName = info.pkgName
 			curStructInfo.filePaths = append(curStructInfo.filePaths, info.filePaths...)
+			// the struct has been created by its methods in the other file
+			curStructInfo.position = info.position
+			curStructInfo.pointerEmbeds = info.pointerEmbeds
 			continue
 		}
 		p.scanner.structs[info.name] = info
@@ -376,7 +451,7 @@ func (p *PackageParser) ParseFile(fileFullPath string, astFile *ast.File) {
 		}
 		structInfo := p.scanner.structs[fullName]
 		if structInfo == nil {
-			structInfo = &StructInfo{BaseInfo: &BaseInfo{name: fullName, filePaths: []string{fileFullPath}}, methods: make(map[string]*MethodInfo)}
+			structInfo = &StructInfo{BaseInfo: &BaseInfo{name: fullName, packageName: p.curPack, pkgName: p.pkgName, filePaths: []string{fileFullPath}, readOnly: p.readOnly}, methods: make(map[string]*MethodInfo), pointerEmbeds: make(map[string]bool)}
 			p.scanner.structs[structInfo.name] = structInfo
 		}
 		lo.ForEach[*MethodInfo](funcs, func(item *MethodInfo, _ int) {
@@ -0,0 +1,96 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package scanner
+
+import "github.com/samber/lo"
+
+// FileResult the syntax info of a file, which doesn't depend on the other files, so it can be cached.
+// The type names in it are the same as the source code, and they are converted to the full names when applying it.
+type FileResult struct {
+	Path        string
+	PackageName string
+	ModTime     int64
+	Size        int64
+	Hash        string
+	// alias -> import path, the aliases include `_` and `.`
+	Imports map[string]string
+	// PlainImports the import paths without the aliases, their names are the names declared by the package clauses
+	PlainImports []string
+	Structs      []string
+	Interfaces   map[string][]*MethodRecord
+	// receiver type name -> methods
+	Funcs           map[string][]*MethodRecord
+	InnerStructs    map[string][]string
+	InnerInterfaces map[string][]string
+	// type name -> the line of the declaration
+	Lines map[string]int
+}
+
+func newFileResult(path string, packageName string) *FileResult {
+	return &FileResult{
+		Path:            path,
+		PackageName:     packageName,
+		Imports:         make(map[string]string),
+		Interfaces:      make(map[string][]*MethodRecord),
+		Funcs:           make(map[string][]*MethodRecord),
+		InnerStructs:    make(map[string][]string),
+		InnerInterfaces: make(map[string][]string),
+		Lines:           make(map[string]int),
+	}
+}
+
+// MethodRecord the exported copy of the MethodInfo
+type MethodRecord struct {
+	Name            string
+	IsPointReceiver bool
+	ReceiverType    string
+	ReceiverName    string
+	Params          []string
+	Returns         []string
+	Line            int
+}
+
+func newMethodRecord(m *MethodInfo) *MethodRecord {
+	return &MethodRecord{
+		Name:            m.name,
+		IsPointReceiver: m.isPointReceiver,
+		ReceiverType:    m.receiverType,
+		ReceiverName:    m.receiverName,
+		Params:          m.params,
+		Returns:         m.returns,
+		Line:            m.position.Line,
+	}
+}
+
+// methodInfo create a new MethodInfo, because the MethodInfo will be modified when applying the result
+func (m *MethodRecord) methodInfo(file string) *MethodInfo {
+	return &MethodInfo{
+		name:            m.Name,
+		isPointReceiver: m.IsPointReceiver,
+		receiverType:    m.ReceiverType,
+		receiverName:    m.ReceiverName,
+		params:          append([]string(nil), m.Params...),
+		returns:         append([]string(nil), m.Returns...),
+		position:        Position{File: file, Line: m.Line},
+	}
+}
+
+func methodInfos(file string, records []*MethodRecord) []*MethodInfo {
+	return lo.Map[*MethodRecord, *MethodInfo](records, func(item *MethodRecord, _ int) *MethodInfo {
+		return item.methodInfo(file)
+	})
+}
@@ -0,0 +1,392 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package scanner
+
+import (
+	"errors"
+	"github.com/SimFG/interfacer/tool"
+	"github.com/fsnotify/fsnotify"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EnableWatch keep the results of the files after the Start, so the Rescan only parses the changed packages
+func (s *Scanner) EnableWatch() {
+	s.enableWatch = true
+	s.files = make(map[string]map[string]fs.FileInfo)
+}
+
+// Watcher notify the paths changed in the scanned dirs by the file system events, see Scanner.Watch
+type Watcher struct {
+	watcher *fsnotify.Watcher
+	delay   time.Duration
+	events  chan []string
+	done    chan struct{}
+}
+
+// Watch watch the dirs of the last scan, and the paths changed in the delay are sent together by the Events,
+// so saving many files at once causes one rescan. The dirs found by the rescan are watched too.
+func (s *Scanner) Watch(delay time.Duration) (*Watcher, error) {
+	if !s.enableWatch {
+		return nil, errors.New("the watch mode isn't enabled before starting the scanner")
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{watcher: watcher, delay: delay, events: make(chan []string), done: make(chan struct{})}
+	for _, dir := range s.parseDirs {
+		if err = watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+	}
+	s.watcher = w
+	go w.run()
+	return w, nil
+}
+
+// Events the changed paths, including the go files, the dirs and the `.gitignore` files, pass them to the Scanner.RescanPaths
+func (w *Watcher) Events() <-chan []string {
+	return w.events
+}
+
+// Close stop watching, the Events channel isn't closed
+func (w *Watcher) Close() error {
+	close(w.done)
+	return w.watcher.Close()
+}
+
+func (w *Watcher) run() {
+	var (
+		paths []string
+		timer <-chan time.Time
+	)
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			// the chmod is sent when the file is touched, the content isn't changed
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			paths = append(paths, filepath.Clean(event.Name))
+			if timer == nil {
+				timer = time.After(w.delay)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			tool.Warn("fail to watch the files", zap.Error(err))
+		case <-timer:
+			select {
+			case <-w.done:
+				return
+			case w.events <- lo.Uniq[string](paths):
+			}
+			paths, timer = nil, nil
+		}
+	}
+}
+
+// Rescan walk the dir of the Start again, and update the packages whose go files are added, removed or changed.
+// The other packages are kept, and the unchanged files are got from the cache. It returns false if nothing is changed.
+func (s *Scanner) Rescan() (bool, error) {
+	if !s.enableWatch {
+		return false, errors.New("the watch mode isn't enabled before starting the scanner")
+	}
+	return s.rescan(make(map[string]bool), true)
+}
+
+// RescanPaths update the packages of the changed paths, like the Events of the Watcher.
+// The dirs are walked again if any dir, `.gitignore` or `go.mod` file is changed, otherwise only the dirs of the go files are checked.
+func (s *Scanner) RescanPaths(paths []string) (bool, error) {
+	if !s.enableWatch {
+		return false, errors.New("the watch mode isn't enabled before starting the scanner")
+	}
+	dirs := make(map[string]bool)
+	walk := false
+	parseDirs := tool.ToMap[string](s.parseDirs)
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		_, isParseDir := parseDirs[path]
+		switch name := filepath.Base(path); {
+		case strings.HasSuffix(name, ".go"):
+			dirs[filepath.Dir(path)] = true
+			// the file in the new dir, the dir maybe is excluded
+			if _, ok := parseDirs[filepath.Dir(path)]; !ok {
+				walk = true
+			}
+		case name == ".gitignore" || name == tool.GoModFile || isParseDir:
+			walk = true
+		default:
+			if fileInfo, err := os.Stat(path); err == nil && fileInfo.IsDir() {
+				walk = true
+			}
+		}
+	}
+	return s.rescan(dirs, walk)
+}
+
+// rescan update the packages of the dirs whose go files are changed, and all dirs are checked if the walk is true
+func (s *Scanner) rescan(dirs map[string]bool, walk bool) (bool, error) {
+	parseDirs := s.parseDirs
+	if walk {
+		s.buildMatcher()
+		var err error
+		if parseDirs, err = s.collectDirs(s.walkDirs(s.dir)); err != nil {
+			return false, err
+		}
+		// the files of all dirs are compared, because the new `.gitignore` maybe excludes or includes the files
+		for _, dir := range append(append([]string{}, parseDirs...), s.parseDirs...) {
+			dirs[dir] = true
+		}
+	}
+	current := tool.ToMap[string](parseDirs)
+
+	var changed []string
+	for dir := range dirs {
+		files := make(map[string]fs.FileInfo)
+		if _, ok := current[dir]; ok {
+			infos, err := s.goFiles(dir)
+			if err != nil && !os.IsNotExist(err) {
+				return false, err
+			}
+			for _, info := range infos {
+				files[tool.PathJoin(dir, info.Name())] = info
+			}
+		}
+		if !sameFiles(s.files[dir], files) {
+			changed = append(changed, dir)
+		}
+	}
+	if s.watcher != nil {
+		last := tool.ToMap[string](s.parseDirs)
+		for _, dir := range parseDirs {
+			if _, ok := last[dir]; ok {
+				continue
+			}
+			if err := s.watcher.watcher.Add(dir); err != nil {
+				tool.Warn("fail to watch the dir", zap.String("dir", dir), zap.Error(err))
+			}
+		}
+	}
+	s.parseDirs = parseDirs
+	if len(changed) == 0 {
+		return false, nil
+	}
+	sort.Strings(changed)
+	tool.Info("Scanner Rescan", zap.String("dir", s.dir), zap.Strings("changed_dirs", changed))
+	return true, s.update(changed, current)
+}
+
+// update parse the packages of the changed dirs again, and replace their types.
+// Only the types of the packages and the types embedding them are linked again, and their entries of the token index are updated.
+func (s *Scanner) update(dirs []string, current map[string]struct{}) error {
+	packages := make(map[string]bool)
+	for _, dir := range dirs {
+		importPath := s.importPath(dir)
+		packages[importPath], packages[importPath+"_test"] = true, true
+	}
+	inPackages := func(name string) bool {
+		i := strings.LastIndex(name, ".")
+		return i > 0 && packages[name[:i]]
+	}
+	// the package clause maybe is changed or removed, and it's recorded again when the package is parsed
+	s.namesMu.Lock()
+	for importPath := range packages {
+		delete(s.packageNames, importPath)
+	}
+	s.namesMu.Unlock()
+
+	// affected the types of the packages and the types embedding them, and staleTokens are their tokens in the token index
+	affected := make(map[string]bool)
+	var staleTokens []string
+	for name, info := range s.structs {
+		if packages[info.packageName] {
+			affected[name] = true
+			staleTokens = append(staleTokens, info.tokens...)
+			delete(s.structs, name)
+		}
+	}
+	for name, info := range s.interfaces {
+		if packages[info.packageName] {
+			affected[name] = true
+			delete(s.interfaces, name)
+		}
+	}
+	s.postParserFuncs = lo.Filter[PostParser](s.postParserFuncs, func(item PostParser, _ int) bool {
+		w, ok := item.(*WrapperFunc)
+		return !ok || !inPackages(w.CurrentName)
+	})
+	s.diagnostics = lo.Filter[*tool.ParseError](s.diagnostics, func(item *tool.ParseError, _ int) bool {
+		return !lo.Contains[string](dirs, filepath.Dir(item.File))
+	})
+
+	// the files are got from the cache, and the results of the deleted files are removed
+	lastFiles := make(map[string]map[string]fs.FileInfo)
+	for _, dir := range dirs {
+		lastFiles[dir] = s.files[dir]
+		delete(s.files, dir)
+	}
+	errs := make([]error, len(dirs))
+	tool.ParallelForEach[int](s.parallel, lo.Range(len(dirs)), func(i int) {
+		if _, ok := current[dirs[i]]; ok {
+			errs[i] = s.parseDir(dirs[i])
+		}
+	})
+	if s.cache != nil {
+		for dir, files := range lastFiles {
+			for path := range files {
+				if _, ok := s.files[dir][path]; !ok {
+					s.cache.Remove(path)
+				}
+			}
+		}
+		s.cache.Save()
+	}
+
+	for name, info := range s.structs {
+		if packages[info.packageName] {
+			affected[name] = true
+		}
+	}
+	for name, info := range s.interfaces {
+		if packages[info.packageName] {
+			affected[name] = true
+		}
+	}
+	s.relink(affected, staleTokens)
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relink link the embedded types of the affected types again, and compute their tokens.
+// The types embedding the affected types are affected too, like the struct embedding the changed struct.
+func (s *Scanner) relink(affected map[string]bool, staleTokens []string) {
+	// inner name -> the names of the types embedding it
+	embedders := make(map[string][]string)
+	lo.ForEach[PostParser](s.postParserFuncs, func(item PostParser, _ int) {
+		if w, ok := item.(*WrapperFunc); ok {
+			embedders[w.InnerName] = append(embedders[w.InnerName], w.CurrentName)
+		}
+	})
+	queue := lo.Keys[string, bool](affected)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, embedder := range embedders[name] {
+			if !affected[embedder] {
+				affected[embedder] = true
+				queue = append(queue, embedder)
+			}
+		}
+	}
+
+	for name := range affected {
+		if info := s.structs[name]; info != nil {
+			staleTokens = append(staleTokens, info.tokens...)
+			info.innerStruct, info.innerInterface = nil, nil
+		}
+		if info := s.interfaces[name]; info != nil {
+			info.innerInterface = nil
+		}
+	}
+	lo.ForEach[PostParser](s.postParserFuncs, func(item PostParser, _ int) {
+		if w, ok := item.(*WrapperFunc); ok && affected[w.CurrentName] {
+			item.Post(s.structs, s.interfaces)
+		}
+	})
+	for name := range affected {
+		if info := s.structs[name]; info != nil {
+			info.Tokens()
+		}
+		if info := s.interfaces[name]; info != nil {
+			info.Tokens()
+		}
+	}
+
+	if s.tokenIndex != nil {
+		for _, token := range lo.Uniq[string](staleTokens) {
+			structs := lo.Filter[*StructInfo](s.tokenIndex[token], func(item *StructInfo, _ int) bool {
+				return !affected[item.name]
+			})
+			if len(structs) == 0 {
+				delete(s.tokenIndex, token)
+				continue
+			}
+			s.tokenIndex[token] = structs
+		}
+		for name := range affected {
+			if info := s.structs[name]; info != nil {
+				for _, token := range lo.Uniq[string](info.tokens) {
+					s.tokenIndex[token] = append(s.tokenIndex[token], info)
+				}
+			}
+		}
+	}
+
+	// the implements of the interfaces are found again when they are got
+	for _, info := range s.interfaces {
+		info.resolved, info.structs = false, nil
+	}
+	if s.enableImplement && tool.IsRecord() {
+		for _, info := range s.interfaces {
+			s.resolveImplements(info)
+		}
+	}
+}
+
+// sameFiles whether the files are the same, including their mtime and size
+func sameFiles(last map[string]fs.FileInfo, current map[string]fs.FileInfo) bool {
+	if len(last) != len(current) {
+		return false
+	}
+	for path, info := range current {
+		lastInfo, ok := last[path]
+		if !ok || !lastInfo.ModTime().Equal(info.ModTime()) || lastInfo.Size() != info.Size() {
+			return false
+		}
+	}
+	return true
+}
+
+// InterfaceNames get the full names of all interfaces, which are sorted
+func (s *Scanner) InterfaceNames() []string {
+	names := make([]string, 0, len(s.interfaces))
+	for name := range s.interfaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
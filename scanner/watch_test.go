@@ -0,0 +1,278 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package scanner
+
+import (
+	"github.com/samber/lo"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// relations get the sorted implements of all interfaces and the tokens of all structs, which are compared with the full scan
+func relations(s *Scanner) map[string][]string {
+	result := make(map[string][]string)
+	for _, name := range s.InterfaceNames() {
+		result["interface "+name] = lo.Map[*StructInfo, string](s.GetInterface(name).GetImplements(), func(item *StructInfo, _ int) string {
+			return item.Name()
+		})
+	}
+	for name, info := range s.structs {
+		result["struct "+name] = append([]string{}, info.tokens...)
+	}
+	for token, structs := range s.tokenIndex {
+		names := lo.Map[*StructInfo, string](structs, func(item *StructInfo, _ int) string {
+			return item.Name()
+		})
+		sort.Strings(names)
+		result["token "+token] = names
+	}
+	return result
+}
+
+func TestRescanPaths(t *testing.T) {
+	base := map[string]string{
+		"go.mod":  "module github.com/foo\n\ngo 1.18\n",
+		"i/i.go":  "package i\n\ntype Base interface {\n\tHello()\n}\n",
+		"a/a.go":  "package a\n\ntype Foo struct{}\n\nfunc (f *Foo) Hello() {}\n",
+		"b/b.go":  "package b\n\nimport \"github.com/foo/a\"\n\ntype Bar struct {\n\ta.Foo\n}\n",
+		"c/c.go":  "package c\n\nimport \"github.com/foo/i\"\n\ntype Top interface {\n\ti.Base\n\tWorld()\n}\n",
+		"d/d.go":  "package d\n\ntype Qux struct{}\n\nfunc (q Qux) World() {}\n",
+		"d/d2.go": "package d\n\nfunc (q Qux) Hello() {}\n",
+		"x/x.go":  "package x\n\ntype Alone struct{}\n\nfunc (a *Alone) Other() {}\n",
+	}
+	tests := []struct {
+		name    string
+		changes map[string]string
+		// unchanged the structs which aren't parsed again
+		unchanged []string
+		want      bool
+	}{
+		{
+			name:      "add the method",
+			changes:   map[string]string{"a/a.go": "package a\n\ntype Foo struct{}\n\nfunc (f *Foo) Hello() {}\n\nfunc (f *Foo) World() {}\n"},
+			unchanged: []string{"github.com/foo/d.Qux", "github.com/foo/x.Alone"},
+			want:      true,
+		},
+		{
+			name:      "remove the method of the embedded struct",
+			changes:   map[string]string{"a/a.go": "package a\n\ntype Foo struct{}\n"},
+			unchanged: []string{"github.com/foo/d.Qux", "github.com/foo/x.Alone"},
+			want:      true,
+		},
+		{
+			name:      "change the embedded interface",
+			changes:   map[string]string{"i/i.go": "package i\n\ntype Base interface {\n\tHello()\n\tBye()\n}\n"},
+			unchanged: []string{"github.com/foo/a.Foo", "github.com/foo/b.Bar", "github.com/foo/d.Qux"},
+			want:      true,
+		},
+		{
+			name:      "delete the file",
+			changes:   map[string]string{"d/d2.go": ""},
+			unchanged: []string{"github.com/foo/a.Foo", "github.com/foo/x.Alone"},
+			want:      true,
+		},
+		{
+			name:      "delete the package",
+			changes:   map[string]string{"a": ""},
+			unchanged: []string{"github.com/foo/d.Qux", "github.com/foo/x.Alone"},
+			want:      true,
+		},
+		{
+			name:      "add the package",
+			changes:   map[string]string{"e/e.go": "package e\n\ntype New struct{}\n\nfunc (n New) Hello() {}\n"},
+			unchanged: []string{"github.com/foo/a.Foo", "github.com/foo/d.Qux"},
+			want:      true,
+		},
+		{
+			name:      "rename the struct",
+			changes:   map[string]string{"x/x.go": "package x\n\ntype Renamed struct{}\n\nfunc (r *Renamed) Hello() {}\n"},
+			unchanged: []string{"github.com/foo/a.Foo", "github.com/foo/d.Qux"},
+			want:      true,
+		},
+		{
+			name:    "other files",
+			changes: map[string]string{"README.md": "# foo\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, base)
+			s := New("github.com/foo", dir)
+			s.DisableProgress()
+			s.EnableWatch()
+			if err := s.Start(dir, nil); err != nil {
+				t.Fatal(err)
+			}
+			relations(s)
+			before := make(map[string]*StructInfo)
+			for _, name := range tt.unchanged {
+				before[name] = s.structs[name]
+			}
+
+			// the mtime is compared, so the changed file must be newer
+			time.Sleep(10 * time.Millisecond)
+			changed, err := s.RescanPaths(writeFiles(t, dir, tt.changes))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != tt.want {
+				t.Errorf("RescanPaths() = %v, want %v", changed, tt.want)
+			}
+			for name, info := range before {
+				if s.structs[name] != info {
+					t.Errorf("the struct %s is parsed again", name)
+				}
+			}
+
+			fresh := New("github.com/foo", dir)
+			fresh.DisableProgress()
+			if err = fresh.Start(dir, nil); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := relations(s), relations(fresh); !reflect.DeepEqual(got, want) {
+				t.Errorf("the relations are different from the full scan:\ngot:  %v\nwant: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module github.com/foo\n\ngo 1.18\n",
+		"a/a.go": "package a\n\ntype Foo struct{}\n",
+	})
+	s := New("github.com/foo", dir)
+	s.DisableProgress()
+	s.EnableWatch()
+	if err := s.Start(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	w, err := s.Watch(10 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	expect := func(path string) {
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case paths := <-w.Events():
+				if lo.Contains[string](paths, path) {
+					if _, err := s.RescanPaths(paths); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+			case <-timeout:
+				t.Fatalf("no event of %s", path)
+			}
+		}
+	}
+	expect(writeFiles(t, dir, map[string]string{"a/b.go": "package a\n\ntype Bar struct{}\n"})[0])
+	if s.structs["github.com/foo/a.Bar"] == nil {
+		t.Error("the new struct isn't found")
+	}
+	// the new dir is watched after the rescan
+	if err = os.Mkdir(filepath.Join(dir, "c"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	expect(filepath.Join(dir, "c"))
+	expect(writeFiles(t, dir, map[string]string{"c/c.go": "package c\n\ntype Qux struct{}\n"})[0])
+	if s.structs["github.com/foo/c.Qux"] == nil {
+		t.Error("the struct in the new dir isn't found")
+	}
+}
+
+func TestRescanDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a/a.go": "package a\n\ntype Foo struct{}\n",
+		"b/b.go": "package b\n\nfunc (\n",
+	})
+	s := New("github.com/foo", dir)
+	s.DisableProgress()
+	s.EnableWatch()
+	if err := s.Start(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if diagnostics := s.Diagnostics(); len(diagnostics) != 1 {
+		t.Fatalf("got the diagnostics %v, want the broken file", diagnostics)
+	}
+
+	// only the diagnostics of the changed dir are replaced
+	time.Sleep(10 * time.Millisecond)
+	if _, err := s.RescanPaths(writeFiles(t, dir, map[string]string{"a/a.go": "package a\n\ntype Foo struct{}\n\nfunc (\n"})); err != nil {
+		t.Fatal(err)
+	}
+	if diagnostics := s.Diagnostics(); len(diagnostics) != 2 {
+		t.Fatalf("got the diagnostics %v, want the two broken files", diagnostics)
+	}
+	if _, err := s.RescanPaths(writeFiles(t, dir, map[string]string{"b/b.go": "package b\n\ntype Bar struct{}\n"})); err != nil {
+		t.Fatal(err)
+	}
+	diagnostics := s.Diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].File != filepath.Join(dir, "a", "a.go") {
+		t.Fatalf("got the diagnostics %v, want the file a.go", diagnostics)
+	}
+	if s.GetStruct("github.com/foo/b.Bar") == nil {
+		t.Error("the struct of the fixed file isn't found")
+	}
+}
+
+func TestRescanPackageName(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes map[string]string
+		want    string
+	}{
+		{name: "rename the package clause", changes: map[string]string{"i2/i.go": "package other\n\ntype Base interface {\n\tHello()\n}\n"}, want: "other"},
+		{name: "delete the package", changes: map[string]string{"i2": ""}, want: "i2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{
+				"go.mod":  "module github.com/foo\n\ngo 1.18\n",
+				"i2/i.go": "package iface\n\ntype Base interface {\n\tHello()\n}\n",
+			})
+			s := New("github.com/foo", dir)
+			s.DisableProgress()
+			s.EnableWatch()
+			if err := s.Start(dir, nil); err != nil {
+				t.Fatal(err)
+			}
+			if got := s.PackageName("github.com/foo/i2"); got != "iface" {
+				t.Fatalf("the package name is %s, want iface", got)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+			if _, err := s.RescanPaths(writeFiles(t, dir, tt.changes)); err != nil {
+				t.Fatal(err)
+			}
+			if got := s.PackageName("github.com/foo/i2"); got != tt.want {
+				t.Errorf("the package name is %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -17,10 +17,74 @@
 package tool
 
 import (
+	"bytes"
 	"go.uber.org/zap"
 	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
 )
 
+// MethodDecl the parsed method declaration, like: Hello(f int64) (int, error)
+type MethodDecl struct {
+	Name string
+	// ParamNames the names of the params, which are empty if the params are unnamed, like: Hello(int)
+	ParamNames  []string
+	ParamTypes  []string
+	ReturnTypes []string
+}
+
+// ParseMethodDecl parse the method declaration by the go parser, so the grouped names, like `Hello(a, b int)`,
+// the unnamed params and the variadic param are supported. The names of the results are dropped.
+// It returns a ConfigError if the declaration is invalid.
+func ParseMethodDecl(method string) (*MethodDecl, error) {
+	method = strings.TrimSpace(method)
+	i := strings.Index(method, "(")
+	if i < 0 {
+		return nil, NewConfigError("method", method, "the method should contain the params in the '(' and ')'")
+	}
+	decl := &MethodDecl{Name: strings.TrimSpace(method[:i])}
+	if !token.IsIdentifier(decl.Name) {
+		return nil, NewConfigError("method", method, "the method should start with a valid method name")
+	}
+	expr, err := parser.ParseExpr("func" + method[i:])
+	if err != nil {
+		return nil, NewConfigError("method", method, err.Error())
+	}
+	funcType, ok := expr.(*ast.FuncType)
+	if !ok {
+		return nil, NewConfigError("method", method, "it should be the declaration without the body")
+	}
+
+	typeString := func(e ast.Expr) string {
+		var buf bytes.Buffer
+		_ = printer.Fprint(&buf, token.NewFileSet(), e)
+		return buf.String()
+	}
+	for _, field := range funcType.Params.List {
+		typ := typeString(field.Type)
+		if len(field.Names) == 0 {
+			decl.ParamNames = append(decl.ParamNames, "")
+			decl.ParamTypes = append(decl.ParamTypes, typ)
+		}
+		for _, name := range field.Names {
+			decl.ParamNames = append(decl.ParamNames, name.Name)
+			decl.ParamTypes = append(decl.ParamTypes, typ)
+		}
+	}
+	if funcType.Results != nil {
+		for _, field := range funcType.Results.List {
+			for n := 0; n < len(field.Names) || n == 0; n++ {
+				decl.ReturnTypes = append(decl.ReturnTypes, typeString(field.Type))
+			}
+		}
+	}
+	Info("method signature", zap.String("func_name", decl.Name), zap.Strings("param_names", decl.ParamNames),
+		zap.Strings("param_types", decl.ParamTypes), zap.Strings("return_types", decl.ReturnTypes))
+	return decl, nil
+}
+
 // GetValueFromType Get value from the `*ast.Ident`/`*ast.SelectorExpr`/`*ast.StarExpr`
 // TODO handle the map / slice / func
 func GetValueFromType(e ast.Expr) string {
@@ -20,90 +20,132 @@ import (
 	"github.com/samber/lo"
 	"go.uber.org/zap"
 	"os"
+	"regexp"
 	"strings"
 )
 
+// ConfigChecker check the params, and it keeps the first error, which is got by the Err
 type ConfigChecker struct {
+	err error
+}
+
+// Err the first error of the checks
+func (c *ConfigChecker) Err() error {
+	return c.err
+}
+
+func (c *ConfigChecker) fail(param string, value string, msg string) {
+	Info("invalid param", zap.String("param", param), zap.String("value", value), zap.String("msg", msg))
+	if c.err == nil {
+		c.err = NewConfigError(param, value, msg)
+	}
 }
 
 // CheckWritePaths the path should be "xxxx,xxxx"
-func (c ConfigChecker) CheckWritePaths(paths []string) {
+func (c *ConfigChecker) CheckWritePaths(paths []string) {
 	lo.ForEach[string](paths, func(item string, index int) {
 		if strings.Index(item, ",") < 0 {
-			Panic("invalid write path", zap.String("path", item))
+			c.fail("write path", item, "it should be like: struct full name,file path")
 		}
 	})
 }
 
 // CheckProjectDir check whether the dir param is existed and valid
-func (c ConfigChecker) CheckProjectDir(dir string) {
+func (c *ConfigChecker) CheckProjectDir(dir string) {
 	fileInfo, err := os.Stat(dir)
 	if err != nil {
-		Panic("invalid project dir", zap.String("dir", dir))
+		c.fail("project dir", dir, err.Error())
+		return
 	}
 	if !fileInfo.IsDir() {
-		Panic("the dir param isn't a dir", zap.String("dir", dir))
+		c.fail("project dir", dir, "it isn't a dir")
 	}
 }
 
+// CheckSubModuleDir check whether the dir of the sub module is found
+func (c *ConfigChecker) CheckSubModuleDir(dir string, module string) {
+	if dir == "" {
+		c.fail("sub module dir", module, "not found the dir of the sub module, please check the go.mod file, or run `go mod download`")
+		return
+	}
+	c.CheckProjectDir(dir)
+}
+
 // CheckModuleName the module shouldn't be empty
-func (c ConfigChecker) CheckModuleName(module string) {
+func (c *ConfigChecker) CheckModuleName(module string) {
 	if module == "" {
-		Panic("the module param shouldn't be empty")
+		c.fail("module", module, "it shouldn't be empty")
 	}
 }
 
 // CheckInterface check the method and the return default values accord the method, if the interface name isn't empty
-func (c ConfigChecker) CheckInterface(interfaceName string, method string, returnDefaultValues string) {
+func (c *ConfigChecker) CheckInterface(interfaceName string, method string, returnDefaultValues string) {
 	if interfaceName == "" {
 		return
 	}
 
-	method = strings.TrimSpace(method)
-	i := strings.Index(method, "(")
-	j := strings.Index(method, ")")
-	x := strings.LastIndex(method, "(")
-	y := strings.LastIndex(method, ")")
+	decl, err := ParseMethodDecl(method)
+	if err != nil {
+		Info("invalid method", zap.String("method", method), zap.Error(err))
+		if c.err == nil {
+			c.err = err
+		}
+		return
+	}
 
-	if i < 0 || j < 0 || i > j {
-		Panic("the method should contain the '(' and ')' orderly",
-			zap.String("method", method))
+	if returnDefaultValues != "" {
+		if len(strings.Split(returnDefaultValues, ",")) != len(decl.ReturnTypes) {
+			c.fail("return default values", returnDefaultValues, "the number of the return default values should be equal the number of the method return values")
+		}
 	}
+}
 
-	if i == 0 {
-		Panic("the method should contain a method name",
-			zap.String("method", method))
+// CheckTestFiles the test files mode should be one of "include", "exclude" and "only"
+func (c *ConfigChecker) CheckTestFiles(mode string) {
+	if !lo.Contains[string]([]string{"include", "exclude", "only"}, mode) {
+		c.fail("test files", mode, "it should be include, exclude or only")
 	}
+}
 
-	if x > y {
-		Panic("the method should contain the '(' and ')' orderly for the more return values",
-			zap.String("method", method))
+// CheckParseErrorMode the mode should be one of "skip_file", "skip_package" and "fail"
+func (c *ConfigChecker) CheckParseErrorMode(mode string) {
+	if !lo.Contains[string]([]string{"skip_file", "skip_package", "fail"}, mode) {
+		c.fail("on parse error", mode, "it should be skip_file, skip_package or fail")
 	}
+}
 
-	params := strings.TrimSpace(method[i+1 : j])
-	if params != "" {
-		lo.ForEach[string](strings.Split(params, ","), func(item string, index int) {
-			if item == "" {
-				Panic("the method name is invalid ",
-					zap.String("method", method))
-			}
-		})
+// CheckInsertPosition the position should be one of "end", "receiver", "alphabetical", "interface" and "after:<method>"
+func (c *ConfigChecker) CheckInsertPosition(position string) {
+	if position == "" || lo.Contains[string]([]string{"end", "receiver", "alphabetical", "interface"}, position) {
+		return
+	}
+	if !strings.HasPrefix(position, "after:") || strings.TrimSpace(strings.TrimPrefix(position, "after:")) == "" {
+		c.fail("insert position", position, "it should be end, receiver, alphabetical, interface or after:<method>")
 	}
+}
 
-	returns := strings.TrimSpace(method[j+1:])
-	returnParams := 0
-	if returns != "" {
-		if strings.Contains(returns, ",") && x < j {
-			Panic("the method has more return values that should be included by the bracket, '()'",
-				zap.String("method", method))
-		}
-		returnParams = len(strings.Split(returns, ","))
+// CheckWriteFile the write file should be one of "source", "per_type" and "per_package"
+func (c *ConfigChecker) CheckWriteFile(mode string) {
+	if mode != "" && !lo.Contains[string]([]string{"source", "per_type", "per_package"}, mode) {
+		c.fail("write file", mode, "it should be source, per_type or per_package")
 	}
+}
 
-	if returnDefaultValues != "" {
-		if len(strings.Split(returnDefaultValues, ",")) != returnParams {
-			Panic("the number of the return default values should be equal the number of the method return values",
-				zap.String("method", method), zap.String("default_values", returnDefaultValues))
+// CheckNamePatterns the pattern with the `regexp:` prefix should be a valid regexp
+func (c *ConfigChecker) CheckNamePatterns(patterns []string) {
+	lo.ForEach[string](patterns, func(item string, index int) {
+		if !strings.HasPrefix(item, RegexpPrefix) {
+			return
+		}
+		if _, err := regexp.Compile(strings.TrimPrefix(item, RegexpPrefix)); err != nil {
+			c.fail("regexp pattern", item, err.Error())
 		}
+	})
+}
+
+// CheckGraphFormat the graph format should be one of the formats
+func (c *ConfigChecker) CheckGraphFormat(format string, formats []string) {
+	if !lo.Contains[string](formats, format) {
+		c.fail("graph format", format, "it should be one of "+strings.Join(formats, ", "))
 	}
 }
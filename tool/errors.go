@@ -0,0 +1,110 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"go/scanner"
+	"strings"
+)
+
+// ErrReadOnly the file is in the read-only dir, like the `vendor` dir
+var ErrReadOnly = errors.New("the file is in the read-only dir")
+
+// ConfigError the invalid param, like the yaml file or the command param
+type ConfigError struct {
+	Param string
+	Value string
+	Msg   string
+}
+
+func NewConfigError(param string, value string, msg string) *ConfigError {
+	return &ConfigError{Param: param, Value: value, Msg: msg}
+}
+
+func (e *ConfigError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("invalid %s: %s", e.Param, e.Msg)
+	}
+	return fmt.Sprintf("invalid %s %q: %s", e.Param, e.Value, e.Msg)
+}
+
+// ParseError the go file can't be parsed, the position is the first syntax error
+type ParseError struct {
+	File   string
+	Line   int
+	Column int
+	Err    error
+}
+
+// NewParseError get the position from the error of the go/parser
+func NewParseError(file string, err error) *ParseError {
+	e := &ParseError{File: file, Err: err}
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		e.Line, e.Column = list[0].Pos.Line, list[0].Pos.Column
+		e.Err = errors.New(list[0].Msg)
+	}
+	return e
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %v", e.File, e.Err)
+	}
+	return fmt.Sprintf("%s:%d:%d: %v", e.File, e.Line, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// NotFoundError the type isn't found, the kind is like `interface` or `struct`
+type NotFoundError struct {
+	Kind string
+	Name string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("not found the %s: %s", e.Kind, e.Name)
+}
+
+// AmbiguousError more than one candidates match the name, like the short interface name or the receiver of the struct
+type AmbiguousError struct {
+	Kind       string
+	Name       string
+	Candidates []string
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("the %s of %s is ambiguous, candidates: %s", e.Kind, e.Name, strings.Join(e.Candidates, ", "))
+}
+
+// WriteError the file can't be written
+type WriteError struct {
+	File string
+	Err  error
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("fail to write %s: %v", e.File, e.Err)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
@@ -75,6 +75,11 @@ func EnableRecord(enable bool) {
 	isRecord = enable
 }
 
+// IsRecord whether to record the relations between all structs and interfaces
+func IsRecord() bool {
+	return isRecord
+}
+
 func EnableDebug(enable bool) {
 	isDebug = enable
 }
@@ -98,10 +103,6 @@ func Warn(msg string, fields ...zap.Field) {
 	}
 }
 
-func Panic(msg string, fields ...zap.Field) {
-	logger.Panic(msg, fields...)
-}
-
 func Debug(msg string, fields ...zap.Field) {
 	debug.Debug(msg, fields...)
 }
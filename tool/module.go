@@ -0,0 +1,300 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package tool
+
+import (
+	"bufio"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	GoModFile  = "go.mod"
+	GoWorkFile = "go.work"
+)
+
+// FindModuleRoot walk up from the dir until finding the dir containing the `go.mod` file.
+// It returns the empty string if not found.
+func FindModuleRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if fileInfo, err := os.Stat(PathJoin(dir, GoModFile)); err == nil && !fileInfo.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ReadModulePath get the module path from the `module` directive of the `go.mod` file
+func ReadModulePath(goModFile string) string {
+	f, err := os.Open(goModFile)
+	if err != nil {
+		Info("fail to open the go.mod file", zap.String("file", goModFile), zap.Error(err))
+		return ""
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "module" {
+			continue
+		}
+		return strings.Trim(fields[1], "\"`")
+	}
+	return ""
+}
+
+// ModulePathOfDir get the import path of the dir by the nearest `go.mod` file, like:
+// the module is `github.com/foo/bar`, and the dir is `$module_root/x/y`, it returns `github.com/foo/bar/x/y`
+func ModulePathOfDir(dir string) string {
+	root := FindModuleRoot(dir)
+	if root == "" {
+		return ""
+	}
+	module := ReadModulePath(PathJoin(root, GoModFile))
+	if module == "" {
+		return ""
+	}
+	dir, _ = filepath.Abs(dir)
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return module
+	}
+	return module + "/" + filepath.ToSlash(rel)
+}
+
+// AbsPath convert the relative path to the absolute path based on the base dir
+func AbsPath(base string, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Clean(filepath.Join(base, p))
+}
+
+// ReadDirectives get the fields of all directives with the verb in the `go.mod` or `go.work` file,
+// including the single line directive, like `use ./foo`, and the block directive, like `use ( ./foo )`
+func ReadDirectives(file string, verb string) [][]string {
+	f, err := os.Open(file)
+	if err != nil {
+		Info("fail to open the file", zap.String("file", file), zap.Error(err))
+		return nil
+	}
+	defer f.Close()
+
+	var (
+		result  [][]string
+		inBlock bool
+	)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := lo.Map[string, string](strings.Fields(line), func(item string, _ int) string {
+			return strings.Trim(item, "\"`")
+		})
+		if len(fields) == 0 {
+			continue
+		}
+		if inBlock {
+			if fields[0] == ")" {
+				inBlock = false
+				continue
+			}
+			result = append(result, fields)
+			continue
+		}
+		if fields[0] != verb {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "(" {
+			inBlock = true
+			continue
+		}
+		result = append(result, fields[1:])
+	}
+	return result
+}
+
+// FindGoWork walk up from the dir to find the `go.work` file, and the GOWORK env has higher priority.
+// It returns the empty string if not found or the GOWORK env is `off`.
+func FindGoWork(dir string) string {
+	if env := os.Getenv("GOWORK"); env != "" {
+		return lo.If[string](env == "off", "").Else(env)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if fileInfo, err := os.Stat(PathJoin(dir, GoWorkFile)); err == nil && !fileInfo.IsDir() {
+			return PathJoin(dir, GoWorkFile)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// WorkspaceModuleDirs get the module dirs from the `use` directives of the `go.work` file
+func WorkspaceModuleDirs(goWorkFile string) []string {
+	base := filepath.Dir(goWorkFile)
+	return lo.FilterMap[[]string, string](ReadDirectives(goWorkFile, "use"), func(item []string, _ int) (string, bool) {
+		if len(item) == 0 {
+			return "", false
+		}
+		return AbsPath(base, item[0]), true
+	})
+}
+
+// LocalReplaces get the replace directives to the local paths in the `go.mod` file, module path -> abs dir, like:
+// `replace github.com/foo/bar => ../bar`
+func LocalReplaces(goModFile string) map[string]string {
+	base := filepath.Dir(goModFile)
+	replaces := make(map[string]string)
+	lo.ForEach[[]string](ReadDirectives(goModFile, "replace"), func(item []string, _ int) {
+		arrow := lo.IndexOf[string](item, "=>")
+		if arrow <= 0 || arrow+1 >= len(item) {
+			return
+		}
+		target := item[arrow+1]
+		// the local path must start with `./` or `../`, or be an abs path, otherwise it's a module path
+		if !filepath.IsAbs(target) && !strings.HasPrefix(target, "./") && !strings.HasPrefix(target, "../") {
+			return
+		}
+		replaces[item[0]] = AbsPath(base, target)
+	})
+	return replaces
+}
+
+// ModCacheDir get the module cache dir, like `go env GOMODCACHE`
+func ModCacheDir() string {
+	if env := os.Getenv("GOMODCACHE"); env != "" {
+		return env
+	}
+	if env := os.Getenv("GOPATH"); env != "" {
+		return PathJoin(filepath.SplitList(env)[0], "pkg", "mod")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return PathJoin(home, "go", "pkg", "mod")
+}
+
+// EscapeModulePath escape the module path for the module cache, the upper letter is replaced by `!` and its lower letter,
+// like: github.com/BurntSushi/toml -> github.com/!burnt!sushi/toml
+func EscapeModulePath(p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('!')
+			b.WriteRune(r + ('a' - 'A'))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ResolveModuleDir find the local dir of the import path offline by the `go.mod` file in the module root,
+// the order is: the local replace directive, the `vendor` dir, and the module cache with the required version.
+// It returns the empty string if not found.
+func ResolveModuleDir(moduleRoot string, importPath string) string {
+	goModFile := PathJoin(moduleRoot, GoModFile)
+	// the import path maybe is a package of the module, so find the module whose path is the longest prefix
+	matchModule := func(modulePath string) (string, bool) {
+		if importPath == modulePath {
+			return "", true
+		}
+		if strings.HasPrefix(importPath, modulePath+"/") {
+			return filepath.FromSlash(importPath[len(modulePath)+1:]), true
+		}
+		return "", false
+	}
+	existDir := func(dir string) bool {
+		fileInfo, err := os.Stat(dir)
+		return err == nil && fileInfo.IsDir()
+	}
+
+	var (
+		bestModule string
+		bestDir    string
+	)
+	for modulePath, dir := range LocalReplaces(goModFile) {
+		if rel, ok := matchModule(modulePath); ok && len(modulePath) > len(bestModule) {
+			bestModule, bestDir = modulePath, filepath.Join(dir, rel)
+		}
+	}
+	if bestDir != "" && existDir(bestDir) {
+		return bestDir
+	}
+
+	if vendorDir := PathJoin(moduleRoot, "vendor", filepath.FromSlash(importPath)); existDir(vendorDir) {
+		return vendorDir
+	}
+
+	// module path -> the module path and version in the module cache
+	requires := make(map[string][2]string)
+	lo.ForEach[[]string](ReadDirectives(goModFile, "require"), func(item []string, _ int) {
+		if len(item) >= 2 {
+			requires[item[0]] = [2]string{item[0], item[1]}
+		}
+	})
+	// the replace directive to the other module version, like `replace foo => bar v1.0.0`
+	lo.ForEach[[]string](ReadDirectives(goModFile, "replace"), func(item []string, _ int) {
+		arrow := lo.IndexOf[string](item, "=>")
+		if arrow <= 0 || arrow+2 >= len(item) {
+			return
+		}
+		if _, ok := requires[item[0]]; ok {
+			requires[item[0]] = [2]string{item[arrow+1], item[arrow+2]}
+		}
+	})
+	bestModule = ""
+	bestDir = ""
+	for modulePath, cacheInfo := range requires {
+		if rel, ok := matchModule(modulePath); ok && len(modulePath) > len(bestModule) {
+			bestModule = modulePath
+			cacheDir := filepath.FromSlash(EscapeModulePath(cacheInfo[0])) + "@" + EscapeModulePath(cacheInfo[1])
+			bestDir = filepath.Join(ModCacheDir(), cacheDir, rel)
+		}
+	}
+	if bestDir != "" && existDir(bestDir) {
+		return bestDir
+	}
+	Info("not found the module dir", zap.String("module_root", moduleRoot), zap.String("import_path", importPath))
+	return ""
+}
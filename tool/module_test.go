@@ -0,0 +1,275 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles create the files in the dir, and the empty content means the dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if content == "" {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestModulePathOfDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"root/go.mod":           "// the root\nmodule \"github.com/foo/bar\" // the module\n\ngo 1.18\n",
+		"root/a/b/x.go":         "package b\n",
+		"root/sub/go.mod":       "module github.com/foo/sub\n",
+		"root/sub/c/x.go":       "package c\n",
+		"root/broken/go.mod":    "go 1.18\n",
+		"root/broken/d/x.go":    "package d\n",
+		"root/gomod_dir/go.mod": "",
+	})
+	tests := []struct {
+		name     string
+		dir      string
+		wantRoot string
+		want     string
+	}{
+		{name: "module root", dir: "root", wantRoot: "root", want: "github.com/foo/bar"},
+		{name: "package dir", dir: "root/a/b", wantRoot: "root", want: "github.com/foo/bar/a/b"},
+		{name: "nested module", dir: "root/sub/c", wantRoot: "root/sub", want: "github.com/foo/sub/c"},
+		{name: "no module directive", dir: "root/broken/d", wantRoot: "root/broken", want: ""},
+		{name: "go.mod dir is ignored", dir: "root/gomod_dir", wantRoot: "root", want: "github.com/foo/bar/gomod_dir"},
+		{name: "no go.mod", dir: ".", wantRoot: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := filepath.Join(dir, filepath.FromSlash(tt.dir))
+			wantRoot := ""
+			if tt.wantRoot != "" {
+				wantRoot = filepath.Join(dir, filepath.FromSlash(tt.wantRoot))
+			}
+			// the temp dir maybe is in a module, like the GOPATH
+			if tt.wantRoot == "" && FindModuleRoot(d) != "" {
+				t.Skip("the temp dir is in a module")
+			}
+			if got := FindModuleRoot(d); got != wantRoot {
+				t.Errorf("FindModuleRoot() = %q, want %q", got, wantRoot)
+			}
+			if got := ModulePathOfDir(d); got != tt.want {
+				t.Errorf("ModulePathOfDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAbsPath(t *testing.T) {
+	base := filepath.FromSlash("/project/conf")
+	tests := []struct {
+		name string
+		p    string
+		want string
+	}{
+		{name: "empty", p: "", want: ""},
+		{name: "abs", p: filepath.FromSlash("/other"), want: filepath.FromSlash("/other")},
+		{name: "relative", p: "../src", want: filepath.FromSlash("/project/src")},
+		{name: "dot", p: ".", want: base},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AbsPath(base, tt.p); got != tt.want {
+				t.Errorf("AbsPath(%q) = %q, want %q", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadDirectives(t *testing.T) {
+	file := filepath.Join(t.TempDir(), GoModFile)
+	writeFiles(t, filepath.Dir(file), map[string]string{GoModFile: `module github.com/foo/bar
+
+require github.com/a/b v1.0.0 // indirect
+
+require (
+	"github.com/c/d" v1.2.0
+	// the comment line
+	github.com/e/f v0.1.0
+)
+
+replace github.com/a/b => ../b
+`})
+	tests := []struct {
+		name string
+		verb string
+		want [][]string
+	}{
+		{name: "single line and block", verb: "require", want: [][]string{
+			{"github.com/a/b", "v1.0.0"}, {"github.com/c/d", "v1.2.0"}, {"github.com/e/f", "v0.1.0"},
+		}},
+		{name: "single line", verb: "replace", want: [][]string{{"github.com/a/b", "=>", "../b"}}},
+		{name: "missing verb", verb: "exclude", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReadDirectives(file, tt.verb); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadDirectives(%q) = %v, want %v", tt.verb, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindGoWork(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.work":  "go 1.18\n\nuse (\n\t./a\n\t../other // the outside module\n)\n\nuse ./b\n",
+		"a/x/x.go": "package x\n",
+		"b/go.mod": "module github.com/foo/b\n",
+		"env.work": "use ./c\n",
+	})
+	tests := []struct {
+		name     string
+		env      string
+		want     string
+		wantDirs []string
+	}{
+		{name: "walk up", want: "go.work", wantDirs: []string{filepath.Join(dir, "a"), filepath.Join(filepath.Dir(dir), "other"), filepath.Join(dir, "b")}},
+		{name: "env", env: filepath.Join(dir, "env.work"), want: "env.work", wantDirs: []string{filepath.Join(dir, "c")}},
+		{name: "env off", env: "off"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOWORK", tt.env)
+			got := FindGoWork(filepath.Join(dir, "a", "x"))
+			want := ""
+			if tt.want != "" {
+				want = filepath.Join(dir, tt.want)
+			}
+			if got != want {
+				t.Fatalf("FindGoWork() = %q, want %q", got, want)
+			}
+			if got == "" {
+				return
+			}
+			if dirs := WorkspaceModuleDirs(got); !reflect.DeepEqual(dirs, tt.wantDirs) {
+				t.Errorf("WorkspaceModuleDirs() = %v, want %v", dirs, tt.wantDirs)
+			}
+		})
+	}
+}
+
+func TestLocalReplaces(t *testing.T) {
+	dir := t.TempDir()
+	abs := filepath.Join(dir, "abs")
+	writeFiles(t, dir, map[string]string{GoModFile: `module github.com/foo/bar
+
+replace (
+	github.com/a/b => ../b
+	github.com/c/d v1.0.0 => ./d
+	github.com/e/f => github.com/e/g v1.1.0
+	github.com/h/i => ` + abs + `
+	github.com/broken =>
+)
+`})
+	want := map[string]string{
+		"github.com/a/b": filepath.Join(filepath.Dir(dir), "b"),
+		"github.com/c/d": filepath.Join(dir, "d"),
+		"github.com/h/i": abs,
+	}
+	if got := LocalReplaces(filepath.Join(dir, GoModFile)); !reflect.DeepEqual(got, want) {
+		t.Errorf("LocalReplaces() = %v, want %v", got, want)
+	}
+}
+
+func TestEscapeModulePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"github.com/foo/bar", "github.com/foo/bar"},
+		{"github.com/BurntSushi/toml", "github.com/!burnt!sushi/toml"},
+		{"v1.0.0-RC1", "v1.0.0-!r!c1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := EscapeModulePath(tt.path); got != tt.want {
+				t.Errorf("EscapeModulePath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveModuleDir(t *testing.T) {
+	dir := t.TempDir()
+	cache := filepath.Join(dir, "cache")
+	t.Setenv("GOMODCACHE", cache)
+	writeFiles(t, dir, map[string]string{
+		"root/go.mod": `module github.com/foo/bar
+
+require (
+	github.com/local/a v1.0.0
+	github.com/vendored/b v1.0.0
+	github.com/Cached/c v1.2.0
+	github.com/cached/c/sub v0.1.0
+	github.com/renamed/d v1.0.0
+	github.com/missing/e v1.0.0
+)
+
+replace github.com/local/a => ../a
+replace github.com/renamed/d => github.com/fork/d v1.1.0
+`,
+		"a/x/x.go":                                  "package x\n",
+		"root/vendor/github.com/vendored/b/b.go":    "package b\n",
+		"root/vendor/github.com/local/a/a.go":       "package a\n",
+		"cache/github.com/!cached/c@v1.2.0/y/y.go":  "package y\n",
+		"cache/github.com/cached/c/sub@v0.1.0/z.go": "package sub\n",
+		"cache/github.com/fork/d@v1.1.0/d.go":       "package d\n",
+	})
+	tests := []struct {
+		name       string
+		importPath string
+		want       string
+	}{
+		{name: "local replace takes precedence over vendor", importPath: "github.com/local/a/x", want: "a/x"},
+		{name: "vendor", importPath: "github.com/vendored/b", want: "root/vendor/github.com/vendored/b"},
+		{name: "module cache with the escaped path", importPath: "github.com/Cached/c/y", want: "cache/github.com/!cached/c@v1.2.0/y"},
+		{name: "longest module path", importPath: "github.com/cached/c/sub", want: "cache/github.com/cached/c/sub@v0.1.0"},
+		{name: "replaced by the other module", importPath: "github.com/renamed/d", want: "cache/github.com/fork/d@v1.1.0"},
+		{name: "not in the cache", importPath: "github.com/missing/e", want: ""},
+		{name: "not required", importPath: "github.com/unknown/f", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := ""
+			if tt.want != "" {
+				want = filepath.Join(dir, filepath.FromSlash(tt.want))
+			}
+			if got := ResolveModuleDir(filepath.Join(dir, "root"), tt.importPath); got != want {
+				t.Errorf("ResolveModuleDir(%q) = %q, want %q", tt.importPath, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package tool
+
+import (
+	"bufio"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const RegexpPrefix = "regexp:"
+
+// PathPattern the gitignore-style pattern, like:
+// `dir1` matches the file or dir named `dir1` at any depth, `internal/gen/**` matches all files in the `internal/gen` dir,
+// `*_mock.go` matches the mock files, `gen/` only matches the dir, and `!keep.go` negates the previous patterns
+type PathPattern struct {
+	base    string
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// NewPathPattern the pattern is relative to the base dir, it returns nil if the pattern is empty or a comment
+func NewPathPattern(base string, pattern string) *PathPattern {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return nil
+	}
+	p := &PathPattern{base: filepath.Clean(base)}
+	if strings.HasPrefix(pattern, "!") {
+		p.negate = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		p.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	// the pattern without the slash matches the name at any depth
+	prefix := "^(.*/)?"
+	if strings.Contains(pattern, "/") {
+		prefix = "^"
+		pattern = strings.TrimPrefix(pattern, "/")
+	}
+	re, err := regexp.Compile(prefix + globToRegexp(pattern) + "$")
+	if err != nil {
+		Warn("invalid path pattern", zap.String("pattern", pattern), zap.Error(err))
+		return nil
+	}
+	p.re = re
+	return p
+}
+
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			if j := strings.IndexByte(glob[i:], ']'); j > 0 {
+				class := glob[i+1 : i+j]
+				// the gitignore negates the class by the `!`, like `[!a]`, and the regexp uses the `^`
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				b.WriteString("[" + class + "]")
+				i += j
+			} else {
+				b.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// Match whether the abs path is matched by the pattern
+func (p *PathPattern) Match(absPath string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	rel, err := filepath.Rel(p.base, absPath)
+	// the name like `..foo` is in the base dir
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return p.re.MatchString(filepath.ToSlash(rel))
+}
+
+// PathMatcher a group of the gitignore-style patterns, the last matched pattern decides the result
+type PathMatcher struct {
+	patterns []*PathPattern
+}
+
+func (m *PathMatcher) Add(base string, patterns ...string) {
+	for _, pattern := range patterns {
+		if p := NewPathPattern(base, pattern); p != nil {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+}
+
+// AddIgnoreFile add the patterns of the `.gitignore` file, which are relative to the dir of the file
+func (m *PathMatcher) AddIgnoreFile(file string) {
+	f, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	Info("add the ignore file", zap.String("file", file))
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		m.Add(filepath.Dir(file), s.Text())
+	}
+}
+
+// Match whether the abs path is excluded by the patterns
+func (m *PathMatcher) Match(absPath string, isDir bool) bool {
+	matched := false
+	for _, p := range m.patterns {
+		if p.Match(absPath, isDir) {
+			matched = !p.negate
+		}
+	}
+	return matched
+}
+
+// NameMatcher match the full name of the type, the pattern can be:
+// the full name, the glob like `github.com/foo/bar/mock.*`, or the regexp with the `regexp:` prefix
+type NameMatcher struct {
+	names map[string]struct{}
+	res   []*regexp.Regexp
+}
+
+func NewNameMatcher(patterns []string) (*NameMatcher, error) {
+	m := &NameMatcher{names: make(map[string]struct{})}
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, RegexpPrefix) {
+			re, err := regexp.Compile(strings.TrimPrefix(pattern, RegexpPrefix))
+			if err != nil {
+				return nil, NewConfigError("regexp pattern", pattern, err.Error())
+			}
+			m.res = append(m.res, re)
+			continue
+		}
+		if strings.ContainsAny(pattern, "*?") {
+			var b strings.Builder
+			for _, c := range pattern {
+				switch c {
+				case '*':
+					b.WriteString(".*")
+				case '?':
+					b.WriteString(".")
+				default:
+					b.WriteString(regexp.QuoteMeta(string(c)))
+				}
+			}
+			re, err := regexp.Compile("^" + b.String() + "$")
+			if err != nil {
+				return nil, NewConfigError("name pattern", pattern, err.Error())
+			}
+			m.res = append(m.res, re)
+			continue
+		}
+		m.names[pattern] = struct{}{}
+	}
+	return m, nil
+}
+
+func (m *NameMatcher) Match(name string) bool {
+	if _, ok := m.names[name]; ok {
+		return true
+	}
+	for _, re := range m.res {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// PackageMatcher match the import path of the package, the pattern can be:
+// the import path, the go tool style pattern like `github.com/foo/bar/internal/storage/...`, or the glob like `github.com/foo/*/storage`.
+// It matches all packages if there is no pattern.
+type PackageMatcher struct {
+	res []*regexp.Regexp
+}
+
+// NewPackageMatcher it returns a ConfigError if the pattern is invalid
+func NewPackageMatcher(patterns []string) (*PackageMatcher, error) {
+	m := &PackageMatcher{}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		var b strings.Builder
+		for i := 0; i < len(pattern); i++ {
+			switch {
+			case strings.HasPrefix(pattern[i:], "/..."):
+				// like the go tool, `foo/...` also matches `foo`
+				b.WriteString("(/.*)?")
+				i += 3
+			case strings.HasPrefix(pattern[i:], "..."):
+				b.WriteString(".*")
+				i += 2
+			case pattern[i] == '*':
+				b.WriteString("[^/]*")
+			case pattern[i] == '?':
+				b.WriteString("[^/]")
+			default:
+				b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		}
+		re, err := regexp.Compile("^" + b.String() + "$")
+		if err != nil {
+			return nil, NewConfigError("package pattern", pattern, err.Error())
+		}
+		m.res = append(m.res, re)
+	}
+	return m, nil
+}
+
+func (m *PackageMatcher) Match(pkg string) bool {
+	if len(m.res) == 0 {
+		return true
+	}
+	for _, re := range m.res {
+		if re.MatchString(pkg) {
+			return true
+		}
+	}
+	return false
+}
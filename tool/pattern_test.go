@@ -0,0 +1,120 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package tool
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPathMatcher(t *testing.T) {
+	base := filepath.FromSlash("/project")
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{"name at any depth", []string{"gen"}, "a/b/gen", true, true},
+		{"name not matched", []string{"gen"}, "a/generated", true, false},
+		{"path with the slash", []string{"internal/gen/**"}, "internal/gen/a/b.go", false, true},
+		{"path is relative to the base", []string{"internal/gen/**"}, "x/internal/gen/a.go", false, false},
+		{"file glob", []string{"*_mock.go"}, "a/foo_mock.go", false, true},
+		{"dir only", []string{"gen/"}, "gen", false, false},
+		{"dir only matches the dir", []string{"gen/"}, "gen", true, true},
+		{"negated pattern", []string{"*.go", "!keep.go"}, "keep.go", false, false},
+		{"comment and empty line", []string{"# gen", ""}, "gen", true, false},
+		{"class", []string{"[ab].go"}, "a.go", false, true},
+		{"negated class", []string{"[!a].go"}, "b.go", false, true},
+		{"negated class excludes", []string{"[!a].go"}, "a.go", false, false},
+		{"double dots name in the base", []string{"..foo"}, "..foo", true, true},
+		{"outside the base", []string{"**"}, "../other", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m PathMatcher
+			m.Add(base, tt.patterns...)
+			if got := m.Match(filepath.Join(base, filepath.FromSlash(tt.path)), tt.isDir); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		typeName string
+		want     bool
+	}{
+		{"full name", []string{"github.com/foo/bar.Mock"}, "github.com/foo/bar.Mock", true},
+		{"full name not matched", []string{"github.com/foo/bar.Mock"}, "github.com/foo/bar.MockX", false},
+		{"glob", []string{"github.com/foo/*.Mock?"}, "github.com/foo/bar.MockA", true},
+		{"regexp", []string{"regexp:.*Mock$"}, "github.com/foo/bar.NodeMock", true},
+		{"regexp not matched", []string{"regexp:.*Mock$"}, "github.com/foo/bar.MockNode", false},
+		{"no pattern", nil, "github.com/foo/bar.Node", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewNameMatcher(tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.Match(tt.typeName); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.typeName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameMatcherInvalidRegexp(t *testing.T) {
+	if _, err := NewNameMatcher([]string{"regexp:(["}); err == nil {
+		t.Fatal("want the error of the invalid regexp")
+	} else if _, ok := err.(*ConfigError); !ok {
+		t.Fatalf("want the ConfigError, got %T", err)
+	}
+}
+
+func TestPackageMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		pkg      string
+		want     bool
+	}{
+		{"no pattern matches all", nil, "github.com/foo/bar", true},
+		{"import path", []string{"github.com/foo/bar"}, "github.com/foo/bar", true},
+		{"go tool pattern", []string{"github.com/foo/..."}, "github.com/foo/bar/baz", true},
+		{"go tool pattern matches the root", []string{"github.com/foo/..."}, "github.com/foo", true},
+		{"go tool pattern not matched", []string{"github.com/foo/..."}, "github.com/foobar", false},
+		{"glob", []string{"github.com/*/storage"}, "github.com/foo/storage", true},
+		{"glob doesn't cross the slash", []string{"github.com/*/storage"}, "github.com/foo/bar/storage", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewPackageMatcher(tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.Match(tt.pkg); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.pkg, got, tt.want)
+			}
+		})
+	}
+}
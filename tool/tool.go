@@ -17,13 +17,15 @@
 package tool
 
 import (
-	"fmt"
+	"crypto/sha256"
+	"encoding/hex"
 	"go.uber.org/zap"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -56,6 +58,9 @@ func PathJoin(paths ...string) string {
 func FileNumInDir(dir string) int {
 	k := 0
 	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
 		if !d.IsDir() {
 			k++
 		}
@@ -65,39 +70,42 @@ func FileNumInDir(dir string) int {
 }
 
 // FileWalk fn, if the return value is false, it won't continue to walk
-func FileWalk(dir string, onlyDir bool, fn func(absPath string, fileInfo os.FileInfo) bool) {
+func FileWalk(dir string, onlyDir bool, fn func(absPath string, fileInfo os.FileInfo) bool) error {
 	Info("FileWalk", zap.String("dir", dir), zap.Bool("only_dir", onlyDir))
 
 	rootInfo, err := os.Stat(dir)
 	if err != nil {
-		Panic("os.Stat err", zap.Error(err))
+		return err
 	}
 	if onlyDir && !rootInfo.IsDir() {
-		return
+		return nil
 	}
 	if ok := fn(dir, rootInfo); !ok {
-		return
+		return nil
 	}
 	if !rootInfo.IsDir() {
-		return
+		return nil
 	}
 	file, err := os.Open(dir)
 	if err != nil {
-		Panic("os.Open err", zap.Error(err))
+		return err
 	}
 
 	fileInfos, err := file.Readdir(0)
 	file.Close()
 	if err != nil {
-		Panic("file.Readdir err", zap.Error(err))
+		return err
 	}
 	for _, fileInfo := range fileInfos {
 		Info("sub_file", zap.String("file_name", fileInfo.Name()))
 		if onlyDir && !fileInfo.IsDir() {
 			continue
 		}
-		FileWalk(PathJoin(file.Name(), fileInfo.Name()), onlyDir, fn)
+		if err = FileWalk(PathJoin(file.Name(), fileInfo.Name()), onlyDir, fn); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // ToMap More functions like it, visit: https://github.com/samber/lo
@@ -109,36 +117,90 @@ func ToMap[T comparable](a []T) map[T]struct{} {
 	return b
 }
 
-// ImportName get the import name from the import spec value
+// ImportName guess the package name from the import path like the goimports, it's used when the package clause can't be read, like:
+// github.com/foo/bar/v2 -> bar, gopkg.in/yaml.v3 -> yaml, github.com/foo/go-bar -> bar
 func ImportName(i string) string {
-	lastSep := strings.LastIndex(i, FileSep)
-	if lastSep == -1 {
-		return i
+	elems := strings.Split(i, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && isMajorVersion(name) {
+		name = elems[len(elems)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if j := strings.IndexAny(name, ".-"); j > 0 {
+		name = name[:j]
 	}
-	return i[lastSep+1:]
+	return name
 }
 
-func TypeString(i interface{}) string {
-	if i == nil {
-		return ""
+// isMajorVersion whether the element of the import path is the major version, like `v2`
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
 	}
-	return reflect.TypeOf(i).String()
+	for _, c := range elem[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
 }
 
-// HandleError panic directly
-func HandleError(e error) {
-	if e != nil {
-		Panic("Panic HandleError", zap.Error(e))
-	}
+// Hash the sha256 hex string of the content
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsTestFile whether the file is a go test file, like `foo_test.go`
+func IsTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.go")
 }
 
-func HandleErrorWithMsg(e error, msg ...string) {
-	if e != nil {
-		fmt.Println(e, msg)
-		Panic("Panic HandleErrorWithMsg", zap.Strings("msg", msg), zap.Error(e))
+func TypeString(i interface{}) string {
+	if i == nil {
+		return ""
 	}
+	return reflect.TypeOf(i).String()
 }
 
 func PrintDetail(objectName string, i interface{}) {
 	Info("object detail", zap.String("objectName", objectName), zap.Any("detail", i))
 }
+
+// ParallelForEach call the fn for each item by n goroutines, and wait for all of them to finish.
+// The first panic of the fn is raised again in the caller goroutine, so it can be recovered by the caller.
+func ParallelForEach[T any](n int, items []T, fn func(item T)) {
+	if n <= 0 {
+		n = 1
+	}
+	ch := make(chan T)
+	w := sync.WaitGroup{}
+	var once sync.Once
+	var panicValue any
+	for i := 0; i < n; i++ {
+		w.Add(1)
+		go func() {
+			defer w.Done()
+			for item := range ch {
+				func() {
+					defer func() {
+						if e := recover(); e != nil {
+							once.Do(func() {
+								panicValue = e
+							})
+						}
+					}()
+					fn(item)
+				}()
+			}
+		}()
+	}
+	for _, item := range items {
+		ch <- item
+	}
+	close(ch)
+	w.Wait()
+	if panicValue != nil {
+		panic(panicValue)
+	}
+}
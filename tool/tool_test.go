@@ -0,0 +1,67 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package tool
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestParallelForEach(t *testing.T) {
+	tests := []struct {
+		name      string
+		n         int
+		items     []int
+		panicItem int
+	}{
+		{name: "serial", n: 1, items: []int{1, 2, 3}},
+		{name: "invalid n is serial", n: 0, items: []int{1, 2, 3}},
+		{name: "more workers than items", n: 8, items: []int{1, 2, 3}},
+		{name: "no item", n: 4},
+		{name: "panic is raised in the caller", n: 4, items: []int{1, 2, 3, 4, 5}, panicItem: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sum int64
+			defer func() {
+				e := recover()
+				if tt.panicItem == 0 {
+					if e != nil {
+						t.Fatalf("unexpected panic: %v", e)
+					}
+					want := 0
+					for _, item := range tt.items {
+						want += item
+					}
+					if int(sum) != want {
+						t.Errorf("the sum is %d, want %d", sum, want)
+					}
+					return
+				}
+				if e != tt.panicItem {
+					t.Errorf("the panic is %v, want %v", e, tt.panicItem)
+				}
+			}()
+			ParallelForEach[int](tt.n, tt.items, func(item int) {
+				if item == tt.panicItem {
+					panic(item)
+				}
+				atomic.AddInt64(&sum, int64(item))
+			})
+		})
+	}
+}
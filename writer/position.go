@@ -0,0 +1,141 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package writer
+
+import (
+	"github.com/SimFG/interfacer/tool"
+	"go/ast"
+	"strings"
+)
+
+const (
+	// InsertEnd the method is added to the end of the interface, and the implementation is added to the end of the file
+	InsertEnd = "end"
+	// InsertAfterReceiver the implementation is added after the last method of the same receiver type in the file
+	InsertAfterReceiver = "receiver"
+	// InsertAfterMethod the method is added after the named sibling method, like `after:Close`
+	InsertAfterMethod = "after"
+	// InsertAlphabetical the method is added before the first method whose name is greater than it
+	InsertAlphabetical = "alphabetical"
+	// InsertInterfaceOrder the implementation mirrors the position of the method in the interface
+	InsertInterfaceOrder = "interface"
+)
+
+// InsertPosition where the new method is inserted in the interface or the file of the struct
+type InsertPosition struct {
+	Mode string
+	// Method the sibling method of the InsertAfterMethod mode
+	Method string
+	// Order the method names of the interface, which is mirrored by the InsertInterfaceOrder mode
+	Order []string
+}
+
+// ParseInsertPosition parse the position like `receiver` or `after:Close`, and the empty value is InsertEnd
+func ParseInsertPosition(value string) InsertPosition {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, InsertAfterMethod+":") {
+		return InsertPosition{Mode: InsertAfterMethod, Method: strings.TrimSpace(value[len(InsertAfterMethod)+1:])}
+	}
+	if value == "" {
+		value = InsertEnd
+	}
+	return InsertPosition{Mode: value}
+}
+
+// index get the index of the existing methods before which the new method is inserted,
+// len(names) means after the last one, and -1 means the end of the interface or the file
+func (p InsertPosition) index(names []string, method string) int {
+	switch p.Mode {
+	case InsertAfterReceiver:
+		return len(names)
+	case InsertAfterMethod:
+		if i := indexOf(names, p.Method); i >= 0 {
+			return i + 1
+		}
+	case InsertAlphabetical:
+		for i, name := range names {
+			if name > method {
+				return i
+			}
+		}
+		return len(names)
+	case InsertInterfaceOrder:
+		k := indexOf(p.Order, method)
+		if k < 0 {
+			return len(names)
+		}
+		// after the nearest previous method of the interface, or before the nearest next one
+		for j := k - 1; j >= 0; j-- {
+			if i := indexOf(names, p.Order[j]); i >= 0 {
+				return i + 1
+			}
+		}
+		for j := k + 1; j < len(p.Order); j++ {
+			if i := indexOf(names, p.Order[j]); i >= 0 {
+				return i
+			}
+		}
+		return len(names)
+	}
+	return -1
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// receiverMethods get the indexes of the declarations and the names of the methods whose receiver is the type, and the pointer is ignored
+func receiverMethods(decls []ast.Decl, receiverType string) ([]int, []string) {
+	var (
+		indexes []int
+		names   []string
+	)
+	receiverType = strings.TrimPrefix(receiverType, "*")
+	for i, decl := range decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+			continue
+		}
+		if strings.TrimPrefix(tool.GetValueFromType(funcDecl.Recv.List[0].Type), "*") == receiverType {
+			indexes = append(indexes, i)
+			names = append(names, funcDecl.Name.Name)
+		}
+	}
+	return indexes, names
+}
+
+// typeDeclIndex get the index of the declaration of the type, it's -1 if the type isn't declared in the file
+func typeDeclIndex(decls []ast.Decl, typeName string) int {
+	typeName = strings.TrimPrefix(typeName, "*")
+	for i, decl := range decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			if typeSpec, ok := spec.(*ast.TypeSpec); ok && typeSpec.Name.Name == typeName {
+				return i
+			}
+		}
+	}
+	return -1
+}
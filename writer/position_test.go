@@ -0,0 +1,150 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package writer
+
+import (
+	"github.com/SimFG/interfacer/tool"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseInsertPosition(t *testing.T) {
+	tests := []struct {
+		value string
+		want  InsertPosition
+	}{
+		{value: "", want: InsertPosition{Mode: InsertEnd}},
+		{value: "receiver", want: InsertPosition{Mode: InsertAfterReceiver}},
+		{value: " alphabetical ", want: InsertPosition{Mode: InsertAlphabetical}},
+		{value: "after: Close", want: InsertPosition{Mode: InsertAfterMethod, Method: "Close"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := ParseInsertPosition(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("the position is %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// declNames get the names of the funcs in the source, like `Foo.Close` for the method, and check the doc of the Close method is kept
+func declNames(t *testing.T, src []byte) []string {
+	fileNode, err := parser.ParseFile(token.NewFileSet(), "a.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, decl := range fileNode.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		name := funcDecl.Name.Name
+		if funcDecl.Recv != nil {
+			name = strings.TrimPrefix(tool.GetValueFromType(funcDecl.Recv.List[0].Type), "*") + "." + name
+		}
+		if name == "Foo.Close" && (funcDecl.Doc == nil || funcDecl.Doc.Text() != "Close closes.\n") {
+			t.Errorf("the doc of the Close method is lost")
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestInsertStructMethod(t *testing.T) {
+	src := "package a\n\n// Foo is foo.\ntype Foo struct{}\n\n// Close closes.\nfunc (f *Foo) Close() error {\n\treturn nil\n}\n\n" +
+		"func (f *Foo) Read() error {\n\treturn nil\n}\n\ntype Bar struct{}\n\nfunc Helper() {}\n"
+	tests := []struct {
+		name         string
+		receiverType string
+		funcName     string
+		position     InsertPosition
+		want         []string
+	}{
+		{name: "end", receiverType: "*Foo", funcName: "Open", position: InsertPosition{Mode: InsertEnd},
+			want: []string{"Foo.Close", "Foo.Read", "Helper", "Foo.Open"}},
+		{name: "receiver", receiverType: "*Foo", funcName: "Open", position: InsertPosition{Mode: InsertAfterReceiver},
+			want: []string{"Foo.Close", "Foo.Read", "Foo.Open", "Helper"}},
+		{name: "alphabetical", receiverType: "*Foo", funcName: "Open", position: InsertPosition{Mode: InsertAlphabetical},
+			want: []string{"Foo.Close", "Foo.Open", "Foo.Read", "Helper"}},
+		{name: "alphabetical first", receiverType: "*Foo", funcName: "Abort", position: InsertPosition{Mode: InsertAlphabetical},
+			want: []string{"Foo.Abort", "Foo.Close", "Foo.Read", "Helper"}},
+		{name: "after the method", receiverType: "*Foo", funcName: "Open", position: InsertPosition{Mode: InsertAfterMethod, Method: "Close"},
+			want: []string{"Foo.Close", "Foo.Open", "Foo.Read", "Helper"}},
+		{name: "after the missing method", receiverType: "*Foo", funcName: "Open", position: InsertPosition{Mode: InsertAfterMethod, Method: "Write"},
+			want: []string{"Foo.Close", "Foo.Read", "Helper", "Foo.Open"}},
+		{name: "interface order", receiverType: "*Foo", funcName: "Open", position: InsertPosition{Mode: InsertInterfaceOrder, Order: []string{"Open", "Close", "Read"}},
+			want: []string{"Foo.Open", "Foo.Close", "Foo.Read", "Helper"}},
+		{name: "no method of the struct", receiverType: "*Bar", funcName: "Open", position: InsertPosition{Mode: InsertAfterReceiver},
+			want: []string{"Foo.Close", "Foo.Read", "Bar.Open", "Helper"}},
+		{name: "existed", receiverType: "*Foo", funcName: "Read", position: InsertPosition{Mode: InsertAlphabetical},
+			want: []string{"Foo.Close", "Foo.Read", "Helper"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newSrc, err := InsertStructMethod("a.go", []byte(src), "f", tt.receiverType, tt.funcName, nil, nil, []string{"error"}, []string{"nil"}, "", tt.position)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := declNames(t, newSrc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("the funcs are %v, want %v\n%s", got, tt.want, newSrc)
+			}
+		})
+	}
+}
+
+func TestInsertInterfaceMethod(t *testing.T) {
+	src := "package i\n\ntype Component interface {\n\t// Close closes.\n\tClose() error\n\tRead() error\n}\n"
+	tests := []struct {
+		name     string
+		method   string
+		position InsertPosition
+		want     []string
+	}{
+		{name: "end", method: "Open() error", position: InsertPosition{Mode: InsertEnd}, want: []string{"Close", "Read", "Open"}},
+		{name: "alphabetical", method: "Open() error", position: InsertPosition{Mode: InsertAlphabetical}, want: []string{"Close", "Open", "Read"}},
+		{name: "alphabetical first", method: "Abort() error", position: InsertPosition{Mode: InsertAlphabetical}, want: []string{"Abort", "Close", "Read"}},
+		{name: "after the method", method: "Open() error", position: InsertPosition{Mode: InsertAfterMethod, Method: "Close"}, want: []string{"Close", "Open", "Read"}},
+		{name: "existed", method: "Read() error", position: InsertPosition{Mode: InsertAlphabetical}, want: []string{"Close", "Read"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newSrc, err := InsertInterfaceMethod("i.go", []byte(src), "Component", tt.method, "", tt.position)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fileNode, err := parser.ParseFile(token.NewFileSet(), "i.go", newSrc, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, field := range fileNode.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.InterfaceType).Methods.List {
+				got = append(got, field.Names[0].Name)
+				if field.Names[0].Name == "Close" && (field.Doc == nil || field.Doc.Text() != "Close closes.\n") {
+					t.Errorf("the doc of the Close method is lost\n%s", newSrc)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("the methods are %v, want %v\n%s", got, tt.want, newSrc)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ package writer
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"github.com/SimFG/interfacer/tool"
 	"github.com/samber/lo"
 	"go.uber.org/zap"
@@ -26,59 +27,102 @@ import (
 	"go/format"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 )
 
-func WriteFile(fileName string, writers []Writer) {
+// CheckWritable return a WriteError if the file is in any of the read-only dirs, like the `vendor` dir
+func CheckWritable(fileName string, readOnlyDirs []string) error {
+	if abs, err := filepath.Abs(fileName); err == nil {
+		fileName = abs
+	}
+	for _, dir := range readOnlyDirs {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		if strings.HasPrefix(fileName, filepath.Clean(dir)+tool.FileSep) {
+			tool.Info("the file is in the read-only dir", zap.String("file_name", fileName), zap.String("dir", dir))
+			return &tool.WriteError{File: fileName, Err: tool.ErrReadOnly}
+		}
+	}
+	return nil
+}
+
+func WriteFile(fileName string, writers []Writer) error {
 	tool.Info("WriteFile", zap.String("file_name", fileName))
 
+	content, err := RewriteSource(fileName, nil, writers)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(fileName, content, 0); err != nil {
+		return &tool.WriteError{File: fileName, Err: err}
+	}
+	return nil
+}
+
+// RewriteSource apply the writers to the source of the file, and get the formatted source. The file is read if the src is nil.
+func RewriteSource(fileName string, src []byte, writers []Writer) ([]byte, error) {
+	tool.Info("RewriteSource", zap.String("file_name", fileName))
+
 	var buf bytes.Buffer
 	fset := token.NewFileSet()
-	fileNode, err := parser.ParseFile(fset, fileName, nil, parser.ParseComments)
-	tool.HandleError(err)
+	fileNode, err := parser.ParseFile(fset, fileName, src, parser.ParseComments)
+	if err != nil {
+		return nil, tool.NewParseError(fileName, err)
+	}
 	for _, writer := range writers {
-		writer.Write(fset, fileNode)
+		if err = writer.Write(fset, fileNode); err != nil {
+			return nil, err
+		}
 	}
 
-	err = format.Node(&buf, fset, fileNode)
-	tool.HandleErrorWithMsg(err, "format node filename:", fileName)
+	if err = format.Node(&buf, fset, fileNode); err != nil {
+		return nil, err
+	}
 	// TODO handle like:
 	//type Component struct {
 	//}
 	//
 	//func (Component) Dummy(){
 	//}
-
-	err = ioutil.WriteFile(fileName, buf.Bytes(), 0)
-	tool.HandleErrorWithMsg(err, "write node filename:", fileName)
+	return buf.Bytes(), nil
 }
 
-func WriteFileForLine(fileName string, writers []Writer) {
+func WriteFileForLine(fileName string, writers []Writer) error {
 	tool.Info("WriteFileForLine", zap.String("file_name", fileName))
 
 	fset := token.NewFileSet()
 	fileNode, err := parser.ParseFile(fset, fileName, nil, parser.ParseComments)
-	tool.HandleError(err)
+	if err != nil {
+		return tool.NewParseError(fileName, err)
+	}
 	for _, writer := range writers {
-		writer.Write(fset, fileNode)
+		if err = writer.Write(fset, fileNode); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 type Writer interface {
-	Write(fset *token.FileSet, fileNode *ast.File)
+	Write(fset *token.FileSet, fileNode *ast.File) error
 }
 
-type WriteFunc func(fset *token.FileSet, fileNode *ast.File)
+type WriteFunc func(fset *token.FileSet, fileNode *ast.File) error
 
-func (w WriteFunc) Write(fset *token.FileSet, fileNode *ast.File) {
-	w(fset, fileNode)
+func (w WriteFunc) Write(fset *token.FileSet, fileNode *ast.File) error {
+	return w(fset, fileNode)
 }
 
+// GetImportWriter add the import to the syntax tree, which may move the comments of the imports, see the InsertImport
 func GetImportWriter(alia string, importValue string) Writer {
-	return WriteFunc(func(fset *token.FileSet, fileNode *ast.File) {
+	return WriteFunc(func(fset *token.FileSet, fileNode *ast.File) error {
 		tool.Info("ImportWriter", zap.String("alia", alia), zap.String("import_value", importValue))
 		var ident *ast.Ident
 		var importSpec *ast.GenDecl
@@ -94,7 +138,7 @@ func GetImportWriter(alia string, importValue string) Writer {
 				Name: ident,
 				Path: &ast.BasicLit{Value: "\"" + importValue + "\""},
 			})
-			return
+			return nil
 		}
 		if importSpec == nil {
 			importSpec = &ast.GenDecl{
@@ -106,8 +150,10 @@ func GetImportWriter(alia string, importValue string) Writer {
 					},
 				},
 			}
-			fileNode.Decls = append(fileNode.Decls, importSpec)
+			// the imports must appear before the other declarations
+			fileNode.Decls = append([]ast.Decl{importSpec}, fileNode.Decls...)
 		}
+		return nil
 	})
 }
 
@@ -138,65 +184,246 @@ func GetIdent(i string) ast.Expr {
 	return result
 }
 
+// RequalifyType rewrite the package qualifiers of the type expression, like `[]*Node` -> `[]*i.Node` or `map[string]s.Foo` -> `map[string]Foo`.
+// The fn gets the qualifier of the type name, which is empty for the unqualified one, and returns the new qualifier, which is empty for dropping it.
+// The predeclared types, like `int` and `error`, are never changed.
+func RequalifyType(typ string, fn func(qualifier string, name string) string) (string, error) {
+	// the variadic param, like `...Node`, isn't a valid expression
+	variadic := strings.HasPrefix(typ, "...")
+	expr, err := parser.ParseExpr(strings.TrimPrefix(typ, "..."))
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if variadic {
+		buf.WriteString("...")
+	}
+	if err = format.Node(&buf, token.NewFileSet(), requalify(expr, fn)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func requalify(expr ast.Expr, fn func(qualifier string, name string) string) ast.Expr {
+	qualified := func(qualifier string, name string) ast.Expr {
+		if qualifier == "" {
+			return &ast.Ident{Name: name}
+		}
+		return &ast.SelectorExpr{X: &ast.Ident{Name: qualifier}, Sel: &ast.Ident{Name: name}}
+	}
+	fields := func(list *ast.FieldList) {
+		if list == nil {
+			return
+		}
+		for _, field := range list.List {
+			field.Type = requalify(field.Type, fn)
+		}
+	}
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if _, ok := types.Universe.Lookup(e.Name).(*types.TypeName); ok {
+			return e
+		}
+		return qualified(fn("", e.Name), e.Name)
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			return qualified(fn(x.Name, e.Sel.Name), e.Sel.Name)
+		}
+	case *ast.StarExpr:
+		e.X = requalify(e.X, fn)
+	case *ast.ParenExpr:
+		e.X = requalify(e.X, fn)
+	case *ast.Ellipsis:
+		e.Elt = requalify(e.Elt, fn)
+	case *ast.ArrayType:
+		e.Elt = requalify(e.Elt, fn)
+	case *ast.MapType:
+		e.Key = requalify(e.Key, fn)
+		e.Value = requalify(e.Value, fn)
+	case *ast.ChanType:
+		e.Value = requalify(e.Value, fn)
+	case *ast.FuncType:
+		fields(e.Params)
+		fields(e.Results)
+	case *ast.StructType:
+		fields(e.Fields)
+	case *ast.InterfaceType:
+		fields(e.Methods)
+	case *ast.IndexExpr:
+		e.X = requalify(e.X, fn)
+		e.Index = requalify(e.Index, fn)
+	case *ast.IndexListExpr:
+		e.X = requalify(e.X, fn)
+		for i := range e.Indices {
+			e.Indices[i] = requalify(e.Indices[i], fn)
+		}
+	}
+	return expr
+}
+
+// GetFuncWriter append the method to the syntax tree, which may move the comments of the file, see the InsertStructMethod
 func GetFuncWriter(receiverName string, receiverType string, funcName string, paramNames []string, paramTypes []string, returnTypes []string, returnDefaultValues []string) Writer {
-	return WriteFunc(func(fset *token.FileSet, fileNode *ast.File) {
+	return WriteFunc(func(fset *token.FileSet, fileNode *ast.File) error {
 		tool.Info("FuncWriter", zap.String("receiver_name", receiverName), zap.String("receiver_type", receiverType),
 			zap.String("func_name", funcName), zap.Strings("param_names", paramNames),
 			zap.Strings("param_types", paramTypes), zap.Strings("return_types", returnTypes),
 			zap.Strings("return_default_values", returnDefaultValues))
 
 		if ExistedMethodForStruct(fileNode.Decls, funcName, receiverType) {
-			return
+			return nil
 		}
 
-		paramFieldList := &ast.FieldList{}
-		if len(paramNames) > 0 {
-			lo.ForEach[string](paramNames, func(item string, index int) {
-				paramFieldList.List = append(paramFieldList.List, &ast.Field{
-					Names: []*ast.Ident{{Name: item}},
-					Type:  GetIdent(paramTypes[index]),
-				})
-			})
-		}
-		returnFieldList := &ast.FieldList{}
-		if len(returnTypes) > 0 {
-			lo.ForEach[string](returnTypes, func(item string, index int) {
-				returnFieldList.List = append(returnFieldList.List, &ast.Field{
-					Type: GetIdent(item),
-				})
+		fileNode.Decls = append(fileNode.Decls, newFuncDecl(receiverName, receiverType, funcName, paramNames, paramTypes, returnTypes, returnDefaultValues))
+		return nil
+	})
+}
+
+func newFuncDecl(receiverName string, receiverType string, funcName string, paramNames []string, paramTypes []string, returnTypes []string, returnDefaultValues []string) *ast.FuncDecl {
+	paramFieldList := &ast.FieldList{}
+	if len(paramNames) > 0 {
+		lo.ForEach[string](paramNames, func(item string, index int) {
+			field := &ast.Field{Type: GetIdent(paramTypes[index])}
+			// the unnamed param, like: Hello(int)
+			if item != "" {
+				field.Names = []*ast.Ident{{Name: item}}
+			}
+			paramFieldList.List = append(paramFieldList.List, field)
+		})
+	}
+	returnFieldList := &ast.FieldList{}
+	if len(returnTypes) > 0 {
+		lo.ForEach[string](returnTypes, func(item string, index int) {
+			returnFieldList.List = append(returnFieldList.List, &ast.Field{
+				Type: GetIdent(item),
 			})
-		}
+		})
+	}
 
-		funcDecl := &ast.FuncDecl{
-			Name: &ast.Ident{Name: funcName},
-			Type: &ast.FuncType{
-				Params:  paramFieldList,
-				Results: returnFieldList,
+	funcDecl := &ast.FuncDecl{
+		Name: &ast.Ident{Name: funcName},
+		Type: &ast.FuncType{
+			Params:  paramFieldList,
+			Results: returnFieldList,
+		},
+		Recv: &ast.FieldList{
+			List: []*ast.Field{
+				{
+					Names: []*ast.Ident{{Name: receiverName}},
+					Type:  GetIdent(receiverType),
+				},
 			},
-			Recv: &ast.FieldList{
-				List: []*ast.Field{
-					{
-						Names: []*ast.Ident{{Name: receiverName}},
-						Type:  GetIdent(receiverType),
+		},
+	}
+
+	if len(returnDefaultValues) > 0 {
+		funcDecl.Body = &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						&ast.Ident{Name: strings.Join(returnDefaultValues, ", ")},
 					},
 				},
 			},
 		}
+	}
+	return funcDecl
+}
 
-		if len(returnDefaultValues) > 0 {
-			funcDecl.Body = &ast.BlockStmt{
-				List: []ast.Stmt{
-					&ast.ReturnStmt{
-						Results: []ast.Expr{
-							&ast.Ident{Name: strings.Join(returnDefaultValues, ", ")},
-						},
-					},
-				},
+// InsertStructMethod insert the method of the struct with the doc to the source at the position by splicing the text, so the comments aren't influenced.
+// Only the new method is formatted, and it's separated from the declarations around it by one empty line, the rest of the source is kept as it is.
+// The source is unchanged if the method has existed.
+func InsertStructMethod(fileName string, src []byte, receiverName string, receiverType string, funcName string, paramNames []string, paramTypes []string, returnTypes []string, returnDefaultValues []string, doc string, position InsertPosition) ([]byte, error) {
+	tool.Info("InsertStructMethod", zap.String("receiver_type", receiverType), zap.String("func_name", funcName), zap.String("position", position.Mode))
+	fset := token.NewFileSet()
+	fileNode, err := parser.ParseFile(fset, fileName, src, parser.ParseComments)
+	if err != nil {
+		return nil, tool.NewParseError(fileName, err)
+	}
+	if ExistedMethodForStruct(fileNode.Decls, funcName, receiverType) {
+		return src, nil
+	}
+
+	var buf bytes.Buffer
+	funcDecl := newFuncDecl(receiverName, receiverType, funcName, paramNames, paramTypes, returnTypes, returnDefaultValues)
+	if doc != "" {
+		buf.WriteString(CommentLines(doc, "") + "\n")
+	}
+	if err = format.Node(&buf, token.NewFileSet(), funcDecl); err != nil {
+		return nil, err
+	}
+	offset := structInsertOffset(fset, fileNode, src, receiverType, funcName, position)
+	head, tail := trimBlankLines(src[:offset], src[offset:])
+	newSrc := make([]byte, 0, len(src)+buf.Len()+4)
+	newSrc = append(append(append(newSrc, head...), "\n\n"...), buf.Bytes()...)
+	newSrc = append(newSrc, '\n')
+	if len(tail) > 0 {
+		newSrc = append(append(newSrc, '\n'), tail...)
+	}
+	return newSrc, nil
+}
+
+// trimBlankLines remove the empty lines at the end of the head and the beginning of the tail, and the line break at the end of the head,
+// so the inserted content between them has the fixed empty lines
+func trimBlankLines(head []byte, tail []byte) ([]byte, []byte) {
+	for {
+		i := bytes.LastIndexByte(head, '\n')
+		if i < 0 || len(bytes.TrimSpace(head[i+1:])) != 0 {
+			break
+		}
+		head = head[:i]
+	}
+	for len(tail) > 0 {
+		i := bytes.IndexByte(tail, '\n')
+		if i < 0 {
+			if len(bytes.TrimSpace(tail)) == 0 {
+				tail = nil
 			}
+			break
 		}
+		if len(bytes.TrimSpace(tail[:i])) != 0 {
+			break
+		}
+		tail = tail[i+1:]
+	}
+	return head, tail
+}
 
-		fileNode.Decls = append(fileNode.Decls, funcDecl)
-	})
+// structInsertOffset get the offset of the source where the method is inserted, it's the end of the declaration line or the beginning of the doc line.
+// If the struct has no method in the file, the method is inserted after the struct declaration, or the end of the file.
+func structInsertOffset(fset *token.FileSet, fileNode *ast.File, src []byte, receiverType string, funcName string, position InsertPosition) int {
+	// after the declaration and the comment at the end of its last line
+	after := func(decl ast.Decl) int {
+		offset := fset.Position(decl.End()).Offset
+		if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+			return offset + i
+		}
+		return len(src)
+	}
+	// before the declaration and its doc, at the beginning of the line
+	before := func(decl *ast.FuncDecl) int {
+		pos := decl.Pos()
+		if decl.Doc != nil {
+			pos = decl.Doc.Pos()
+		}
+		offset := fset.Position(pos).Offset
+		return bytes.LastIndexByte(src[:offset], '\n') + 1
+	}
+
+	indexes, names := receiverMethods(fileNode.Decls, receiverType)
+	i := position.index(names, funcName)
+	switch {
+	case i < 0:
+		return len(src)
+	case len(names) == 0:
+		if j := typeDeclIndex(fileNode.Decls, receiverType); j >= 0 {
+			return after(fileNode.Decls[j])
+		}
+		return len(src)
+	case i < len(names):
+		return before(fileNode.Decls[indexes[i]].(*ast.FuncDecl))
+	default:
+		return after(fileNode.Decls[indexes[len(names)-1]])
+	}
 }
 
 func ExistedMethodForStruct(decls []ast.Decl, method string, receiverType string) bool {
@@ -224,7 +451,7 @@ func ExistedMethodForStruct(decls []ast.Decl, method string, receiverType string
 }
 
 func GetInterfaceWrite(interfaceName string, funcName string, paramNames []string, paramTypes []string, returnTypes []string) Writer {
-	return WriteFunc(func(fset *token.FileSet, fileNode *ast.File) {
+	return WriteFunc(func(fset *token.FileSet, fileNode *ast.File) error {
 		tool.Info("InterfaceWrite",
 			zap.String("func_name", funcName), zap.Strings("param_names", paramNames),
 			zap.Strings("param_types", paramTypes), zap.Strings("return_types", returnTypes))
@@ -277,41 +504,231 @@ func GetInterfaceWrite(interfaceName string, funcName string, paramNames []strin
 			interfaceType.Methods.List = append(interfaceType.Methods.List, methodField)
 			return false
 		})
+		return nil
 	})
 }
 
 // GetInterfaceWrite2 dismiss the influence of the comment
 func GetInterfaceWrite2(fileName string, interfaceName string, method string) Writer {
-	return WriteFunc(func(fset *token.FileSet, fileNode *ast.File) {
+	return WriteFunc(func(fset *token.FileSet, fileNode *ast.File) error {
 		tool.Info("InterfaceWrite2", zap.String("interface_name", interfaceName), zap.String("method", method))
-		var (
-			ok            bool
-			interfaceType *ast.InterfaceType
-			typeSpec      *ast.TypeSpec
-		)
-		ast.Inspect(fileNode, func(x ast.Node) bool {
-			if typeSpec, ok = x.(*ast.TypeSpec); !ok {
-				return true
+		if line, ok := interfaceInsertLine(fset, fileNode, interfaceName, method, InsertPosition{Mode: InsertEnd}); ok {
+			return FileInsertContent(fileName, line, method)
+		}
+		return nil
+	})
+}
+
+// InsertInterfaceMethod insert the method to the interface in the source by the line at the position, so the comments aren't influenced.
+// The file is read if the src is nil.
+func InsertInterfaceMethod(fileName string, src []byte, interfaceName string, method string, doc string, position InsertPosition) ([]byte, error) {
+	tool.Info("InsertInterfaceMethod", zap.String("interface_name", interfaceName), zap.String("method", method), zap.String("position", position.Mode))
+	if src == nil {
+		var err error
+		if src, err = os.ReadFile(fileName); err != nil {
+			return nil, err
+		}
+	}
+	fset := token.NewFileSet()
+	fileNode, err := parser.ParseFile(fset, fileName, src, parser.ParseComments)
+	if err != nil {
+		return nil, tool.NewParseError(fileName, err)
+	}
+	if line, ok := interfaceInsertLine(fset, fileNode, interfaceName, method, position); ok {
+		if doc != "" {
+			indent := method[:len(method)-len(strings.TrimLeft(method, " \t"))]
+			method = CommentLines(doc, indent) + "\n" + method
+		}
+		return InsertContent(src, line, method)
+	}
+	return src, nil
+}
+
+// CommentLines convert the doc to the line comments with the indent, and the line beginning with `//` is kept, like:
+// "Hello says hello.\nIt's safe." -> "// Hello says hello.\n// It's safe."
+func CommentLines(doc string, indent string) string {
+	lines := strings.Split(strings.TrimSpace(doc), "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "//"):
+		case line == "":
+			line = "//"
+		default:
+			line = "// " + line
+		}
+		lines[i] = indent + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// InsertImport add the import to the source by splicing the text, so the comments aren't influenced.
+// The import is added to the last group of the first import declaration except the `import "C"` in the order of the gofmt,
+// or a new declaration after the package clause, and the rest of the source is kept as it is. It's unchanged if the import has existed.
+func InsertImport(fileName string, src []byte, alias string, importPath string) ([]byte, error) {
+	tool.Info("InsertImport", zap.String("alias", alias), zap.String("import_path", importPath))
+	fset := token.NewFileSet()
+	fileNode, err := parser.ParseFile(fset, fileName, src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil, tool.NewParseError(fileName, err)
+	}
+	for _, spec := range fileNode.Imports {
+		if path, _ := strconv.Unquote(spec.Path.Value); path == importPath && (spec.Name == nil && alias == "" || spec.Name != nil && spec.Name.Name == alias) {
+			return src, nil
+		}
+	}
+
+	offset := func(pos token.Pos) int {
+		return fset.Position(pos).Offset
+	}
+	lineStart := func(offset int) int {
+		return bytes.LastIndexByte(src[:offset], '\n') + 1
+	}
+	lineEnd := func(offset int) int {
+		if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+			return offset + i
+		}
+		return len(src)
+	}
+	// whether the offset is the first token of the line
+	firstInLine := func(offset int) bool {
+		return strings.TrimSpace(string(src[lineStart(offset):offset])) == ""
+	}
+	spec := strconv.Quote(importPath)
+	if alias != "" {
+		spec = alias + " " + spec
+	}
+
+	// the new declaration after the package clause or the `import "C"`
+	start, end, content := lineEnd(offset(fileNode.Name.End())), -1, "\n\nimport "+spec
+	for _, decl := range fileNode.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		// the comment of the `import "C"` is the preamble of the cgo, so it can't be changed
+		if len(genDecl.Specs) == 1 && genDecl.Specs[0].(*ast.ImportSpec).Path.Value == `"C"` {
+			start = lineEnd(offset(genDecl.End()))
+			continue
+		}
+		if genDecl.Lparen.IsValid() {
+			// before the greater import and its doc, or before the `)`, and the comments in the block are kept
+			start = offset(genDecl.Rparen)
+			content = "\n\t" + spec + "\n"
+			if next := nextImport(fset, genDecl, alias, importPath); next != nil {
+				pos := next.Pos()
+				if next.Doc != nil {
+					pos = next.Doc.Pos()
+				}
+				start = offset(pos)
 			}
-			if interfaceType, ok = typeSpec.Type.(*ast.InterfaceType); !ok {
-				return true
+			if firstInLine(start) {
+				start = lineStart(start)
+				content = "\t" + spec + "\n"
 			}
-			typeName := typeSpec.Name.Name
-			if typeName != interfaceName {
-				return true
+		} else {
+			// convert the single import to the block, and the comment at the end of the line is kept
+			start, end = offset(genDecl.Pos()), lineEnd(offset(genDecl.End()))
+			existed := string(src[offset(genDecl.Specs[0].Pos()):end])
+			content = "import (\n\t" + existed + "\n\t" + spec + "\n)"
+			if importLess(alias, importPath, genDecl.Specs[0].(*ast.ImportSpec)) {
+				content = "import (\n\t" + spec + "\n\t" + existed + "\n)"
 			}
+		}
+		break
+	}
+	if end < 0 {
+		end = start
+	}
 
-			tool.Info("InterfaceWrite2 hit")
-			funcName := strings.TrimSpace(method[:strings.Index(method, "(")])
-			funcName = strings.Trim(funcName, "\\t")
-			if ExistedMethodForInterface(interfaceType.Methods, funcName) {
-				return false
-			}
-			pos := fset.Position(x.End())
-			FileInsertContent(fileName, pos.Line-1, method)
+	newSrc := make([]byte, 0, len(src)+len(content))
+	newSrc = append(append(append(newSrc, src[:start]...), content...), src[end:]...)
+	return newSrc, nil
+}
+
+// nextImport get the first import greater than the new one in the last group of the import block, it's nil if the new one is the greatest.
+// The group is the imports in the consecutive lines, which are sorted by the gofmt.
+func nextImport(fset *token.FileSet, genDecl *ast.GenDecl, alias string, importPath string) *ast.ImportSpec {
+	group := 0
+	for i := 1; i < len(genDecl.Specs); i++ {
+		if fset.Position(genDecl.Specs[i].Pos()).Line > fset.Position(genDecl.Specs[i-1].End()).Line+1 {
+			group = i
+		}
+	}
+	for _, spec := range genDecl.Specs[group:] {
+		if importLess(alias, importPath, spec.(*ast.ImportSpec)) {
+			return spec.(*ast.ImportSpec)
+		}
+	}
+	return nil
+}
+
+// importLess whether the new import is before the import spec in the order of the gofmt, which compares the paths and then the names
+func importLess(alias string, importPath string, spec *ast.ImportSpec) bool {
+	path, _ := strconv.Unquote(spec.Path.Value)
+	if importPath != path {
+		return importPath < path
+	}
+	name := ""
+	if spec.Name != nil {
+		name = spec.Name.Name
+	}
+	return alias < name
+}
+
+// interfaceInsertLine get the line after which the method is inserted, it's the line before the end of the interface by default,
+// and it's false if the interface isn't found or the method has existed
+func interfaceInsertLine(fset *token.FileSet, fileNode *ast.File, interfaceName string, method string, position InsertPosition) (int, bool) {
+	var (
+		ok            bool
+		interfaceType *ast.InterfaceType
+		typeSpec      *ast.TypeSpec
+		line          int
+		found         bool
+	)
+	ast.Inspect(fileNode, func(x ast.Node) bool {
+		if typeSpec, ok = x.(*ast.TypeSpec); !ok {
+			return true
+		}
+		if interfaceType, ok = typeSpec.Type.(*ast.InterfaceType); !ok {
+			return true
+		}
+		typeName := typeSpec.Name.Name
+		if typeName != interfaceName {
+			return true
+		}
+
+		tool.Info("InterfaceWrite2 hit")
+		funcName := strings.TrimSpace(method[:strings.Index(method, "(")])
+		funcName = strings.Trim(funcName, "\t")
+		if ExistedMethodForInterface(interfaceType.Methods, funcName) {
 			return false
+		}
+		line, found = fset.Position(x.End()).Line-1, true
+		var (
+			fields []*ast.Field
+			names  []string
+		)
+		lo.ForEach[*ast.Field](interfaceType.Methods.List, func(item *ast.Field, _ int) {
+			if len(item.Names) != 0 {
+				fields = append(fields, item)
+				names = append(names, item.Names[0].Name)
+			}
 		})
+		switch i := position.index(names, funcName); {
+		case i > 0 && i < len(names):
+			line = fset.Position(fields[i-1].End()).Line
+		case i == 0 && len(names) > 0:
+			// before the first method and its doc
+			first := fields[0].Pos()
+			if fields[0].Doc != nil {
+				first = fields[0].Doc.Pos()
+			}
+			line = fset.Position(first).Line - 1
+		}
+		return false
 	})
+	return line, found
 }
 
 func ExistedMethodForInterface(list *ast.FieldList, methodName string) bool {
@@ -325,38 +742,39 @@ func ExistedMethodForInterface(list *ast.FieldList, methodName string) bool {
 	return hasExist
 }
 
-func FileInsertContent(fileName string, line int, content string) {
+func FileInsertContent(fileName string, line int, content string) error {
 	tool.Info("FileInsertContent", zap.String("file_name", fileName), zap.Int("line", line), zap.String("content", content))
-	file, err := os.OpenFile(fileName, os.O_RDWR, 0)
-	tool.HandleErrorWithMsg(err, "File open failed!")
-
-	reader := bufio.NewReader(file)
+	src, err := os.ReadFile(fileName)
+	if err != nil {
+		return &tool.WriteError{File: fileName, Err: err}
+	}
 
-	tempFile, err := os.OpenFile(fileName+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0766)
-	tool.HandleErrorWithMsg(err, "Temp create failed!")
+	newSrc, err := InsertContent(src, line, content)
+	if err != nil {
+		return &tool.WriteError{File: fileName, Err: err}
+	}
+	if err = os.WriteFile(fileName+".tmp", newSrc, 0766); err != nil {
+		return &tool.WriteError{File: fileName, Err: err}
+	}
+	if err = os.Rename(fileName+".tmp", fileName); err != nil {
+		return &tool.WriteError{File: fileName, Err: err}
+	}
+	return nil
+}
 
-	writer := bufio.NewWriter(tempFile)
-	_ = writer.Flush()
+// InsertContent insert the content after the first lines of the source, and an empty line is added before the content
+func InsertContent(src []byte, line int, content string) ([]byte, error) {
+	reader := bufio.NewReader(bytes.NewReader(src))
+	var buf bytes.Buffer
 	for i := 0; i < line; i++ {
 		l, err := reader.ReadString('\n')
-		tool.HandleErrorWithMsg(err, "File raed failed!")
-
-		_, _ = writer.WriteString(l)
-		_ = writer.Flush()
-	}
-	_, _ = tempFile.WriteString("\n" + content + "\n")
-	for {
-		l, err := reader.ReadString('\n')
-		if err == io.EOF {
-			break
+		if err != nil {
+			return nil, fmt.Errorf("the source has less than %d lines: %w", line, err)
 		}
-		tool.HandleErrorWithMsg(err, "File raed failed!")
-		_, _ = writer.WriteString(l)
+		buf.WriteString(l)
 	}
-	_ = writer.Flush()
-
-	file.Close()
-	tempFile.Close()
-	err = os.Rename(fileName+".tmp", fileName)
-	tool.HandleErrorWithMsg(err, "Rename file raed failed!")
+	buf.WriteString("\n" + content + "\n")
+	rest, _ := io.ReadAll(reader)
+	buf.Write(rest)
+	return buf.Bytes(), nil
 }
@@ -0,0 +1,155 @@
+/*
+ * // Copyright 2022 The SimFG Authors
+ * //
+ * // Licensed under the Apache License, Version 2.0 (the "License");
+ * // you may not use this file except in compliance with the License.
+ * // You may obtain a copy of the License at
+ * //
+ * //     http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing, software
+ * // distributed under the License is distributed on an "AS IS" BASIS,
+ * // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * // See the License for the specific language governing permissions and
+ * // limitations under the License.
+ */
+
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"github.com/SimFG/interfacer/tool"
+	"go/format"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestInsertGolden(t *testing.T) {
+	insertMethod := func(position string) func(src []byte) ([]byte, error) {
+		return func(src []byte) ([]byte, error) {
+			return InsertStructMethod("foo.go", src, "f", "*Foo", "Hello", []string{"ctx", "names"}, []string{"context.Context", "...string"},
+				[]string{"int", "error"}, []string{"0", "nil"}, "Hello says hello.\nIt's generated.", ParseInsertPosition(position))
+		}
+	}
+	insertInterfaceMethod := func(position string) func(src []byte) ([]byte, error) {
+		return func(src []byte) ([]byte, error) {
+			return InsertInterfaceMethod("foo.go", src, "Component", "\tHello(ctx context.Context) error", "Hello says hello.", ParseInsertPosition(position))
+		}
+	}
+	insertImport := func(alias string, importPath string) func(src []byte) ([]byte, error) {
+		return func(src []byte) ([]byte, error) {
+			return InsertImport("foo.go", src, alias, importPath)
+		}
+	}
+	tests := []struct {
+		name   string
+		insert func(src []byte) ([]byte, error)
+		// unformatted the input isn't formatted, and it's kept in the output
+		unformatted bool
+	}{
+		{name: "struct_end", insert: insertMethod("end")},
+		{name: "struct_receiver", insert: insertMethod("receiver")},
+		{name: "struct_alphabetical", insert: insertMethod("alphabetical")},
+		{name: "struct_no_method", insert: insertMethod("receiver"), unformatted: true},
+		{name: "interface_end", insert: insertInterfaceMethod("end")},
+		{name: "interface_alphabetical", insert: insertInterfaceMethod("alphabetical")},
+		{name: "import_grouped", insert: insertImport("", "github.com/samber/lo")},
+		{name: "import_grouped_first", insert: insertImport("", "github.com/foo/bar")},
+		{name: "import_single", insert: insertImport("", "context")},
+		{name: "import_aliased", insert: insertImport("pbv1", "github.com/foo/proto/v1")},
+		{name: "import_none", insert: insertImport("", "context")},
+		{name: "import_cgo", insert: insertImport("", "unsafe")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := filepath.Join("testdata", tt.name+".input")
+			src, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.insert(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if formatted, err := format.Source(got); err != nil {
+				t.Fatalf("the output can't be parsed: %v\n%s", err, got)
+			} else if !tt.unformatted && !bytes.Equal(formatted, got) {
+				t.Errorf("the output isn't formatted:\n%s", got)
+			}
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err = os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("the output is different from %s:\n%s", golden, got)
+			}
+
+			// the second insertion finds the existing method or import
+			again, err := tt.insert(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(again, got) {
+				t.Errorf("the second insertion changes the output:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+	readOnlyDirs := []string{filepath.Join(dir, "vendor")}
+	tests := []struct {
+		name     string
+		file     string
+		dirs     []string
+		readOnly bool
+	}{
+		{name: "in the read-only dir", file: "vendor/github.com/dep/d/d.go", dirs: readOnlyDirs, readOnly: true},
+		{name: "outside the read-only dir", file: "a/a.go", dirs: readOnlyDirs},
+		{name: "the dir having the same prefix", file: "vendored/a.go", dirs: readOnlyDirs},
+		{name: "no read-only dir", file: "vendor/github.com/dep/d/d.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckWritable(filepath.Join(dir, filepath.FromSlash(tt.file)), tt.dirs)
+			if errors.Is(err, tool.ErrReadOnly) != tt.readOnly {
+				t.Errorf("got the error %v, want the read-only error: %v", err, tt.readOnly)
+			}
+		})
+	}
+}
+
+func TestCommentLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    string
+		indent string
+		want   string
+	}{
+		{name: "one line", doc: "Hello says hello.", want: "// Hello says hello."},
+		{name: "many lines", doc: "Hello says hello.\nIt's safe.\n", indent: "\t", want: "\t// Hello says hello.\n\t// It's safe."},
+		{name: "comment prefix", doc: "// Hello says hello.\n//It's safe.", want: "// Hello says hello.\n//It's safe."},
+		{name: "empty line", doc: "Hello says hello.\n\nIt's safe.", want: "// Hello says hello.\n//\n// It's safe."},
+		{name: "spaces", doc: "  Hello says hello.  \n\t  It's safe.", indent: "\t", want: "\t// Hello says hello.\n\t// It's safe."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CommentLines(tt.doc, tt.indent); got != tt.want {
+				t.Errorf("the comment is %q, want %q", got, tt.want)
+			}
+		})
+	}
+}